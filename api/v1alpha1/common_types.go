@@ -0,0 +1,26 @@
+package v1alpha1
+
+// SecretReference references a Secret in the same namespace as the referencing object.
+// The Secret uses the same fields as annotated secrets (access-key, secret-key, ...).
+type SecretReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+}
+
+const (
+	// ConditionReady indicates whether the resource has been provisioned on the backend
+	ConditionReady = "Ready"
+
+	// ReasonReconciled means the backend matches the spec
+	ReasonReconciled = "Reconciled"
+	// ReasonSecretNotFound means the referenced Secret does not exist
+	ReasonSecretNotFound = "SecretNotFound"
+	// ReasonInvalidSpec means the spec or the referenced Secret is incomplete or invalid
+	ReasonInvalidSpec = "InvalidSpec"
+	// ReasonBackendError means a backend call failed
+	ReasonBackendError = "BackendError"
+	// ReasonBucketOwnedByOtherAccount means the bucket exists but the backend denies the operator access to it
+	ReasonBucketOwnedByOtherAccount = "BucketOwnedByOtherAccount"
+	// ReasonBucketClaimConflict means the bucket is claimed by another secret or S3Bucket
+	ReasonBucketClaimConflict = "BucketClaimConflict"
+)
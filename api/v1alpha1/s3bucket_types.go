@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BucketDeletionPolicy controls what happens to the backend bucket when an S3Bucket is deleted
+// +kubebuilder:validation:Enum=Retain;Delete;Purge
+type BucketDeletionPolicy string
+
+const (
+	// BucketDeletionPolicyRetain keeps the bucket
+	BucketDeletionPolicyRetain BucketDeletionPolicy = "Retain"
+	// BucketDeletionPolicyDelete deletes the bucket if it contains no objects
+	BucketDeletionPolicyDelete BucketDeletionPolicy = "Delete"
+	// BucketDeletionPolicyPurge deletes all objects and the bucket
+	BucketDeletionPolicyPurge BucketDeletionPolicy = "Purge"
+)
+
+// S3BucketSpec defines the desired state of S3Bucket
+type S3BucketSpec struct {
+	// BucketName is the name of the bucket on the backend. Defaults to the object name.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="bucketName is immutable"
+	// +optional
+	BucketName string `json:"bucketName,omitempty"`
+
+	// SecretRef references the Secret holding the access-key of the bucket owner
+	SecretRef SecretReference `json:"secretRef"`
+
+	// Policy is a bucket policy preset (read-only, read-write, public-read) or a JSON policy document.
+	// With read-only and read-write the owner of an existing bucket is not changed.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// DeletionPolicy controls whether the bucket is deleted with the S3Bucket
+	// +kubebuilder:default=Retain
+	// +optional
+	DeletionPolicy BucketDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// S3BucketStatus defines the observed state of S3Bucket
+type S3BucketStatus struct {
+	// BucketName is the name of the provisioned bucket
+	// +optional
+	BucketName string `json:"bucketName,omitempty"`
+
+	// Owner is the access key the bucket was provisioned for
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// ObservedGeneration is the generation last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the current state of the bucket
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Bucket",type=string,JSONPath=`.status.bucketName`
+// +kubebuilder:printcolumn:name="Owner",type=string,JSONPath=`.status.owner`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// S3Bucket is the Schema for the s3buckets API
+type S3Bucket struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   S3BucketSpec   `json:"spec,omitempty"`
+	Status S3BucketStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// S3BucketList contains a list of S3Bucket
+type S3BucketList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []S3Bucket `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&S3Bucket{}, &S3BucketList{})
+}
+
+// GetBucketName returns the backend bucket name, defaulting to the object name
+func (b *S3Bucket) GetBucketName() string {
+	if b.Spec.BucketName != "" {
+		return b.Spec.BucketName
+	}
+	return b.Name
+}
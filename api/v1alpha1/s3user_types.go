@@ -0,0 +1,82 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserDeletionPolicy controls what happens to the backend user when an S3User is deleted
+// +kubebuilder:validation:Enum=Retain;Delete
+type UserDeletionPolicy string
+
+const (
+	// UserDeletionPolicyRetain keeps the backend user
+	UserDeletionPolicyRetain UserDeletionPolicy = "Retain"
+	// UserDeletionPolicyDelete deletes the backend user
+	UserDeletionPolicyDelete UserDeletionPolicy = "Delete"
+)
+
+// S3UserSpec defines the desired state of S3User
+type S3UserSpec struct {
+	// SecretRef references the Secret holding the user's access-key and secret-key
+	SecretRef SecretReference `json:"secretRef"`
+
+	// Role assigned to the user (VersityGW role, MinIO policy name)
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// UserID assigned to the user
+	// +optional
+	UserID *int `json:"userID,omitempty"`
+
+	// GroupID assigned to the user
+	// +optional
+	GroupID *int `json:"groupID,omitempty"`
+
+	// DeletionPolicy controls whether the backend user is deleted with the S3User
+	// +kubebuilder:default=Retain
+	// +optional
+	DeletionPolicy UserDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// S3UserStatus defines the observed state of S3User
+type S3UserStatus struct {
+	// AccessKey of the provisioned backend user
+	// +optional
+	AccessKey string `json:"accessKey,omitempty"`
+
+	// ObservedGeneration is the generation last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the current state of the user
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Access Key",type=string,JSONPath=`.status.accessKey`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// S3User is the Schema for the s3users API
+type S3User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   S3UserSpec   `json:"spec,omitempty"`
+	Status S3UserStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// S3UserList contains a list of S3User
+type S3UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []S3User `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&S3User{}, &S3UserList{})
+}
@@ -1,20 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"text/template"
+	"time"
 
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
 	"github.com/runningman84/s3-resource-operator/pkg/backends"
 	"github.com/runningman84/s3-resource-operator/pkg/controller"
 	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var (
@@ -23,6 +34,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(s3v1alpha1.AddToScheme(scheme))
 }
 
 var (
@@ -32,9 +44,39 @@ var (
 	rootAccessKey   = flag.String("root-access-key", "", "Root access key for S3 backend")
 	rootSecretKey   = flag.String("root-secret-key", "", "Root secret key for S3 backend")
 	backendName     = flag.String("backend-name", "versitygw", "Backend type (versitygw, minio, garage)")
+	adminEndpoint   = flag.String("admin-endpoint-url", "", "Admin API endpoint URL for backends with a separate admin port (garage)")
+	adminToken      = flag.String("admin-token", "", "Admin API bearer token for backends with a separate admin port (garage)")
 	enforceEndpoint = flag.Bool("enforce-endpoint-check", true, "Skip secrets with mismatched endpoint URLs")
+	deletionPolicy  = flag.String("deletion-policy", "retain", "Default deletion policy for secrets (retain, delete-user, delete-user-and-empty-bucket, delete-all)")
+	rotationPeriod  = flag.String("rotation-period", "0", "Default secret key rotation period, e.g. 90d or 2160h (0 disables rotation)")
+	clusterName     = flag.String("cluster-name", "", "Name of this cluster, recorded in the tags of managed buckets")
+	bucketTemplate  = flag.String("bucket-name-template", "", "Template deriving bucket names from secrets, e.g. {{.Namespace}}-{{.BucketName}}")
+	encryption      = flag.String("default-encryption", "", "Default bucket encryption (SSE-S3, SSE-KMS or none); empty leaves bucket encryption unmanaged")
+	kmsKeyID        = flag.String("default-kms-key-id", "", "KMS key ID for the default SSE-KMS bucket encryption")
+	resyncInterval  = flag.Duration("resync-interval", 10*time.Minute, "Interval for re-verifying users and buckets against the backend (0 disables resyncs)")
+	enableCRDs      = flag.Bool("enable-crd-controllers", true, "Reconcile S3Bucket and S3User custom resources (requires the CRDs to be installed)")
+	watchNamespaces = flag.String("watch-namespaces", "", "Comma-separated namespaces to watch secrets in (default all namespaces)")
+	excludeNS       = flag.String("exclude-namespaces", "", "Comma-separated namespaces whose secrets are ignored")
+	secretSelector  = flag.String("secret-label-selector", "", "Label selector restricting the watched secrets, e.g. team=payments")
+	enableWebhook   = flag.Bool("enable-webhook", false, "Serve a validating admission webhook for annotated secrets")
+	webhookPort     = flag.Int("webhook-port", 9443, "Port of the validating admission webhook")
+	webhookCertDir  = flag.String("webhook-cert-dir", "", "Directory with the tls.crt and tls.key of the webhook (default <temp-dir>/k8s-webhook-server/serving-certs)")
+	leaderElect     = flag.Bool("leader-elect", false, "Elect a leader among the operator replicas, only the leader reconciles")
+	leaderElectID   = flag.String("leader-election-id", "s3-resource-operator-leader", "Name of the lease used for leader election")
+	leaderElectNS   = flag.String("leader-election-namespace", "", "Namespace of the leader election lease (default the operator's namespace)")
+	leaseDuration   = flag.Duration("leader-election-lease-duration", 15*time.Second, "Time standby replicas wait before taking over a lease that was not renewed")
+	renewDeadline   = flag.Duration("leader-election-renew-deadline", 10*time.Second, "Time the leader retries renewing its lease before giving it up")
+	retryPeriod     = flag.Duration("leader-election-retry-period", 2*time.Second, "Interval between attempts to acquire or renew the lease")
+	backendsConfig  = flag.String("backends-config", "", "Path to a YAML or JSON file listing additional backends")
+	backendSecretNS = flag.String("backend-secrets-namespace", "", "Namespace of secrets labelled "+backendSecretLabel+" that configure additional backends")
 )
 
+// backendSecretLabel marks operator secrets that configure a backend; its value is the backend name
+const backendSecretLabel = "s3-resource-operator.io/backend"
+
+// defaultBackendName is the registry name of the backend configured by flags or environment variables
+const defaultBackendName = "default"
+
 func main() {
 	opts := zap.Options{
 		Development: true,
@@ -56,57 +98,203 @@ func main() {
 	if *rootSecretKey == "" {
 		*rootSecretKey = os.Getenv("ROOT_SECRET_KEY")
 	}
+	if *adminEndpoint == "" {
+		*adminEndpoint = os.Getenv("ADMIN_ENDPOINT_URL")
+	}
+	if *adminToken == "" {
+		*adminToken = os.Getenv("ADMIN_TOKEN")
+	}
 	if os.Getenv("BACKEND_NAME") != "" {
 		*backendName = os.Getenv("BACKEND_NAME")
 	}
 	if os.Getenv("ANNOTATION_KEY") != "" {
 		*annotationKey = os.Getenv("ANNOTATION_KEY")
 	}
+	if os.Getenv("DELETION_POLICY") != "" {
+		*deletionPolicy = os.Getenv("DELETION_POLICY")
+	}
+	if os.Getenv("ROTATION_PERIOD") != "" {
+		*rotationPeriod = os.Getenv("ROTATION_PERIOD")
+	}
+	if *clusterName == "" {
+		*clusterName = os.Getenv("CLUSTER_NAME")
+	}
+	if *bucketTemplate == "" {
+		*bucketTemplate = os.Getenv("BUCKET_NAME_TEMPLATE")
+	}
+	if *watchNamespaces == "" {
+		*watchNamespaces = os.Getenv("WATCH_NAMESPACES")
+	}
+	if *excludeNS == "" {
+		*excludeNS = os.Getenv("EXCLUDE_NAMESPACES")
+	}
+	if *secretSelector == "" {
+		*secretSelector = os.Getenv("SECRET_LABEL_SELECTOR")
+	}
+	if *encryption == "" {
+		*encryption = os.Getenv("DEFAULT_ENCRYPTION")
+	}
+	if *kmsKeyID == "" {
+		*kmsKeyID = os.Getenv("DEFAULT_KMS_KEY_ID")
+	}
+	if *backendsConfig == "" {
+		*backendsConfig = os.Getenv("BACKENDS_CONFIG")
+	}
+	if *backendSecretNS == "" {
+		*backendSecretNS = os.Getenv("BACKEND_SECRETS_NAMESPACE")
+	}
+	if value := os.Getenv("RESYNC_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			setupLog.Error(err, "Invalid RESYNC_INTERVAL value")
+			os.Exit(1)
+		}
+		*resyncInterval = interval
+	}
+	if value := os.Getenv("ENABLE_CRD_CONTROLLERS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			setupLog.Error(err, "Invalid ENABLE_CRD_CONTROLLERS value")
+			os.Exit(1)
+		}
+		*enableCRDs = enabled
+	}
+	if value := os.Getenv("ENABLE_WEBHOOK"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			setupLog.Error(err, "Invalid ENABLE_WEBHOOK value")
+			os.Exit(1)
+		}
+		*enableWebhook = enabled
+	}
+	if *webhookCertDir == "" {
+		*webhookCertDir = os.Getenv("WEBHOOK_CERT_DIR")
+	}
+	if value := os.Getenv("LEADER_ELECT"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			setupLog.Error(err, "Invalid LEADER_ELECT value")
+			os.Exit(1)
+		}
+		*leaderElect = enabled
+	}
+	if os.Getenv("LEADER_ELECTION_ID") != "" {
+		*leaderElectID = os.Getenv("LEADER_ELECTION_ID")
+	}
+	if *leaderElectNS == "" {
+		*leaderElectNS = os.Getenv("LEADER_ELECTION_NAMESPACE")
+	}
+	for env, duration := range map[string]*time.Duration{
+		"LEADER_ELECTION_LEASE_DURATION": leaseDuration,
+		"LEADER_ELECTION_RENEW_DEADLINE": renewDeadline,
+		"LEADER_ELECTION_RETRY_PERIOD":   retryPeriod,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				setupLog.Error(err, "Invalid "+env+" value")
+				os.Exit(1)
+			}
+			*duration = parsed
+		}
+	}
 
-	// Validate required configuration
-	if *s3EndpointURL == "" || *rootAccessKey == "" || *rootSecretKey == "" {
-		setupLog.Error(fmt.Errorf("missing required configuration"), "Missing S3_ENDPOINT_URL, ROOT_ACCESS_KEY, or ROOT_SECRET_KEY")
+	defaultDeletionPolicy, err := controller.ParseDeletionPolicy(*deletionPolicy)
+	if err != nil {
+		setupLog.Error(err, "Invalid deletion policy")
 		os.Exit(1)
 	}
 
-	setupLog.Info("Starting S3 Resource Operator",
-		"backend", *backendName,
-		"annotationKey", *annotationKey,
-		"endpoint", *s3EndpointURL)
-
-	// Initialize metrics
-	metrics.Register()
+	defaultRotationPeriod, err := controller.ParseRotationPeriod(*rotationPeriod)
+	if err != nil {
+		setupLog.Error(err, "Invalid rotation period")
+		os.Exit(1)
+	}
 
-	// Initialize backend
-	backend, err := backends.NewBackend(*backendName, backends.Config{
-		EndpointURL: *s3EndpointURL,
-		AccessKey:   *rootAccessKey,
-		SecretKey:   *rootSecretKey,
-	})
+	defaultEncryption, err := controller.ParseEncryption(*encryption, *kmsKeyID)
 	if err != nil {
-		setupLog.Error(err, "Failed to initialize backend")
+		setupLog.Error(err, "Invalid default bucket encryption")
 		os.Exit(1)
 	}
 
-	// Test backend connection
-	setupLog.Info("Testing backend connection...")
-	ctx := ctrl.SetupSignalHandler()
-	if err := backend.TestConnection(ctx); err != nil {
-		setupLog.Error(err, "Backend connection test failed")
+	var bucketNameTemplate *template.Template
+	if *bucketTemplate != "" {
+		bucketNameTemplate, err = controller.ParseBucketNameTemplate(*bucketTemplate)
+		if err != nil {
+			setupLog.Error(err, "Invalid bucket name template")
+			os.Exit(1)
+		}
+	}
+
+	scope, err := controller.NewWatchScope(*watchNamespaces, *excludeNS, *secretSelector)
+	if err != nil {
+		setupLog.Error(err, "Invalid watch scope")
 		os.Exit(1)
 	}
-	setupLog.Info("Backend connection test passed")
 
 	// Get Kubernetes config (controller-runtime handles this automatically via flags)
 	config := ctrl.GetConfigOrDie()
 
+	// Initialize backends
+	registry, err := loadBackends(config)
+	if err != nil {
+		setupLog.Error(err, "Failed to initialize backends")
+		os.Exit(1)
+	}
+
+	setupLog.Info("Starting S3 Resource Operator",
+		"backends", registry.Names(),
+		"annotationKey", *annotationKey,
+		"deletionPolicy", defaultDeletionPolicy,
+		"rotationPeriod", defaultRotationPeriod,
+		"encryption", *encryption,
+		"clusterName", *clusterName,
+		"bucketNameTemplate", *bucketTemplate,
+		"watchNamespaces", scope.Namespaces,
+		"excludeNamespaces", scope.ExcludeNamespaces,
+		"secretLabelSelector", *secretSelector,
+		"resyncInterval", *resyncInterval,
+		"crdControllers", *enableCRDs,
+		"webhook", *enableWebhook,
+		"leaderElection", *leaderElect)
+
+	// Initialize metrics
+	metrics.Register()
+
+	// Test backend connections
+	ctx := ctrl.SetupSignalHandler()
+	for _, name := range registry.Names() {
+		backend, _ := registry.Get(name)
+		setupLog.Info("Testing backend connection...", "backend", name, "endpoint", backend.GetEndpointURL())
+		if err := backend.TestConnection(ctx); err != nil {
+			setupLog.Error(err, "Backend connection test failed", "backend", name)
+			os.Exit(1)
+		}
+	}
+	setupLog.Info("Backend connection tests passed")
+	backend := registry.Default()
+
 	// Create manager
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: fmt.Sprintf(":%d", *metricsPort),
 		},
-		HealthProbeBindAddress: fmt.Sprintf(":%d", *metricsPort+1),
+		HealthProbeBindAddress:  fmt.Sprintf(":%d", *metricsPort+1),
+		Cache:                   scope.CacheOptions(),
+		LeaderElection:          *leaderElect,
+		LeaderElectionID:        *leaderElectID,
+		LeaderElectionNamespace: *leaderElectNS,
+		LeaseDuration:           leaseDuration,
+		RenewDeadline:           renewDeadline,
+		RetryPeriod:             retryPeriod,
+		// The process exits right after the manager stops, so the lease can be handed over
+		// without waiting for it to expire
+		LeaderElectionReleaseOnCancel: true,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    *webhookPort,
+			CertDir: *webhookCertDir,
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "Unable to create manager")
@@ -118,26 +306,141 @@ func main() {
 		setupLog.Error(err, "Unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", func(_ *http.Request) error { return nil }); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", leaderCheck(mgr)); err != nil {
 		setupLog.Error(err, "Unable to set up ready check")
 		os.Exit(1)
 	}
 
 	// Create and register reconciler
-	if err = controller.NewSecretReconciler(
+	reconciler := controller.NewSecretReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
 		backend,
 		*annotationKey,
 		*enforceEndpoint,
-	).SetupWithManager(mgr); err != nil {
+	)
+	reconciler.Backends = registry
+	reconciler.Scope = scope
+	reconciler.APIReader = mgr.GetAPIReader()
+	reconciler.DeletionPolicy = defaultDeletionPolicy
+	reconciler.ClusterName = *clusterName
+	reconciler.BucketNameTemplate = bucketNameTemplate
+	reconciler.Encryption = defaultEncryption
+	reconciler.Recorder = mgr.GetEventRecorder("s3-resource-operator")
+	reconciler.RotationPeriod = defaultRotationPeriod
+	reconciler.ResyncInterval = *resyncInterval
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "Unable to create controller")
 		os.Exit(1)
 	}
 
+	if *enableWebhook {
+		if err = reconciler.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create webhook", "webhook", "Secret")
+			os.Exit(1)
+		}
+	}
+
+	if *enableCRDs {
+		userReconciler := controller.NewS3UserReconciler(mgr.GetClient(), mgr.GetScheme(), backend)
+		userReconciler.Recorder = mgr.GetEventRecorder("s3-resource-operator")
+		userReconciler.ResyncInterval = *resyncInterval
+		userReconciler.APIReader = mgr.GetAPIReader()
+		userReconciler.Backends = registry
+		if err = userReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create controller", "controller", "S3User")
+			os.Exit(1)
+		}
+		bucketReconciler := controller.NewS3BucketReconciler(mgr.GetClient(), mgr.GetScheme(), backend)
+		bucketReconciler.Recorder = mgr.GetEventRecorder("s3-resource-operator")
+		bucketReconciler.ResyncInterval = *resyncInterval
+		bucketReconciler.ClusterName = *clusterName
+		bucketReconciler.Scope = scope
+		bucketReconciler.AnnotationKey = *annotationKey
+		bucketReconciler.APIReader = mgr.GetAPIReader()
+		bucketReconciler.Backends = registry
+		if err = bucketReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create controller", "controller", "S3Bucket")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("Starting manager")
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "Problem running manager")
 		os.Exit(1)
 	}
 }
+
+// leaderCheck reports a replica ready once it holds the leader election lease, so only the
+// leader receives traffic. Replicas are ready right away without leader election, and a leader
+// that loses its lease exits.
+func leaderCheck(mgr ctrl.Manager) healthz.Checker {
+	return func(_ *http.Request) error {
+		select {
+		case <-mgr.Elected():
+			return nil
+		default:
+			return errors.New("waiting to be elected leader")
+		}
+	}
+}
+
+// loadBackends builds the backend registry from the flag or environment configuration,
+// the backends configuration file and the labelled backend secrets
+func loadBackends(config *rest.Config) (*backends.Registry, error) {
+	registry := backends.NewRegistry()
+
+	if *s3EndpointURL != "" || *rootAccessKey != "" || *rootSecretKey != "" {
+		if err := registry.Add(backends.BackendConfig{
+			Name:             defaultBackendName,
+			Type:             *backendName,
+			EndpointURL:      *s3EndpointURL,
+			AccessKey:        *rootAccessKey,
+			SecretKey:        *rootSecretKey,
+			AdminEndpointURL: *adminEndpoint,
+			AdminToken:       *adminToken,
+			Default:          true,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if *backendsConfig != "" {
+		configs, err := backends.LoadRegistryConfig(*backendsConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, backendConfig := range configs {
+			if err := registry.Add(backendConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if *backendSecretNS != "" {
+		// The manager's cache is not running yet, read the secrets directly
+		c, err := client.New(config, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, err
+		}
+		var secrets corev1.SecretList
+		if err := c.List(context.Background(), &secrets, client.InNamespace(*backendSecretNS), client.HasLabels{backendSecretLabel}); err != nil {
+			return nil, fmt.Errorf("failed to list backend secrets: %w", err)
+		}
+		for _, secret := range secrets.Items {
+			name := secret.Labels[backendSecretLabel]
+			if name == "" {
+				name = secret.Name
+			}
+			if err := registry.Add(backends.BackendConfigFromSecret(name, secret.Data)); err != nil {
+				return nil, fmt.Errorf("secret %s/%s: %w", secret.Namespace, secret.Name, err)
+			}
+		}
+	}
+
+	if registry.Default() == nil {
+		return nil, fmt.Errorf("no backend configured: set S3_ENDPOINT_URL, ROOT_ACCESS_KEY and ROOT_SECRET_KEY, BACKENDS_CONFIG or BACKEND_SECRETS_NAMESPACE")
+	}
+	return registry, nil
+}
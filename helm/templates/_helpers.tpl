@@ -74,3 +74,113 @@ Create the name of the secret to use
 {{- .Values.operator.secret.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+RBAC rules of the operator, granted cluster-wide or in each watched namespace
+*/}}
+{{- define "s3-resource-operator.rbacRules" -}}
+- apiGroups:
+  - ""
+  resources:
+  - secrets
+  - services
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - events.k8s.io
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+- apiGroups:
+  - s3-resource-operator.io
+  resources:
+  - s3buckets
+  - s3users
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+- apiGroups:
+  - s3-resource-operator.io
+  resources:
+  - s3buckets/status
+  - s3users/status
+  verbs:
+  - get
+  - update
+  - patch
+- apiGroups:
+  - s3-resource-operator.io
+  resources:
+  - s3buckets/finalizers
+  - s3users/finalizers
+  verbs:
+  - update
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - versity.io
+  resources:
+  - versitygw
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - patch
+  - delete
+- apiGroups:
+  - versity.io
+  resources:
+  - versitygw/finalizers
+  verbs:
+  - update
+- apiGroups:
+  - versity.io
+  resources:
+  - versitygw/status
+  verbs:
+  - get
+  - patch
+  - update
+{{- end }}
+
+{{/*
+Namespaces the operator gets a Role in when operator.watch_namespaces is set: the watched
+namespaces, and the release namespace for backend secrets
+*/}}
+{{- define "s3-resource-operator.roleNamespaces" -}}
+{{- $namespaces := .Values.operator.watch_namespaces }}
+{{- if .Values.operator.backend_secrets }}
+{{- $namespaces = append $namespaces .Release.Namespace }}
+{{- end }}
+{{- $namespaces | uniq | join "," }}
+{{- end }}
+
+{{/*
+Whether the operator replicas elect a leader: when enabled or with more than one replica
+*/}}
+{{- define "s3-resource-operator.leaderElection" -}}
+{{- if or .Values.leaderElection.enabled (gt (int .Values.replicaCount) 1) }}true{{- end }}
+{{- end }}
@@ -1,6 +1,9 @@
 package backends
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // Backend defines the interface for S3-compatible storage backends
 type Backend interface {
@@ -10,6 +13,7 @@ type Backend interface {
 	// Bucket operations
 	CreateBucket(ctx context.Context, bucketName string, owner *string) error
 	DeleteBucket(ctx context.Context, bucketName string) error
+	EmptyBucket(ctx context.Context, bucketName string) error
 	BucketExists(ctx context.Context, bucketName string) (bool, error)
 	GetBucketOwner(ctx context.Context, bucketName string) (string, error)
 	ChangeBucketOwner(ctx context.Context, bucketName, newOwner string) error
@@ -24,11 +28,162 @@ type Backend interface {
 	GetEndpointURL() string
 }
 
+// BucketPolicyManager is implemented by backends that support S3 bucket policies
+type BucketPolicyManager interface {
+	// GetBucketPolicy returns the bucket policy, or an empty string if none is set
+	GetBucketPolicy(ctx context.Context, bucketName string) (string, error)
+	PutBucketPolicy(ctx context.Context, bucketName, policy string) error
+	DeleteBucketPolicy(ctx context.Context, bucketName string) error
+}
+
+// BucketVersioningManager is implemented by backends that support S3 bucket versioning
+type BucketVersioningManager interface {
+	// GetBucketVersioning reports whether versioning is enabled on the bucket
+	GetBucketVersioning(ctx context.Context, bucketName string) (bool, error)
+	// PutBucketVersioning enables or suspends versioning
+	PutBucketVersioning(ctx context.Context, bucketName string, enabled bool) error
+}
+
+// ObjectLockManager is implemented by backends that support S3 object lock
+type ObjectLockManager interface {
+	// CreateBucketWithObjectLock creates a bucket with object lock enabled. Most backends
+	// only allow enabling object lock when a bucket is created.
+	CreateBucketWithObjectLock(ctx context.Context, bucketName string, owner *string) error
+	// GetObjectLockConfiguration returns the object lock configuration, or nil if object
+	// lock is not enabled on the bucket
+	GetObjectLockConfiguration(ctx context.Context, bucketName string) (*ObjectLockConfiguration, error)
+	// PutObjectLockConfiguration enables object lock and sets the default retention
+	PutObjectLockConfiguration(ctx context.Context, bucketName string, config ObjectLockConfiguration) error
+}
+
+// Object lock retention modes
+const (
+	RetentionModeGovernance = "GOVERNANCE"
+	RetentionModeCompliance = "COMPLIANCE"
+)
+
+// ObjectLockConfiguration is the default retention of a bucket with object lock enabled
+type ObjectLockConfiguration struct {
+	// Mode is RetentionModeGovernance or RetentionModeCompliance, or empty for no default retention
+	Mode string
+	// Days is the default retention period in days
+	Days int
+}
+
+// LifecycleManager is implemented by backends that support S3 lifecycle configuration
+type LifecycleManager interface {
+	// GetBucketLifecycle returns the lifecycle rules of the bucket, or nil if it has none
+	GetBucketLifecycle(ctx context.Context, bucketName string) ([]LifecycleRule, error)
+	PutBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) error
+	DeleteBucketLifecycle(ctx context.Context, bucketName string) error
+}
+
+// LifecycleRule is a bucket lifecycle rule. Zero day counts leave the action out.
+type LifecycleRule struct {
+	ID       string `json:"id"`
+	Prefix   string `json:"prefix,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// ExpirationDays deletes current object versions after the given number of days
+	ExpirationDays int `json:"expirationDays,omitempty"`
+	// NoncurrentVersionExpirationDays deletes noncurrent object versions after the given number of days
+	NoncurrentVersionExpirationDays int `json:"noncurrentVersionExpirationDays,omitempty"`
+	// AbortIncompleteMultipartUploadDays aborts multipart uploads after the given number of days
+	AbortIncompleteMultipartUploadDays int `json:"abortIncompleteMultipartUploadDays,omitempty"`
+}
+
+// BucketTaggingManager is implemented by backends that support S3 bucket tagging
+type BucketTaggingManager interface {
+	// GetBucketTagging returns the tags of the bucket, or an empty map if it has none
+	GetBucketTagging(ctx context.Context, bucketName string) (map[string]string, error)
+	// PutBucketTagging replaces the tags of the bucket
+	PutBucketTagging(ctx context.Context, bucketName string, tags map[string]string) error
+}
+
+// EncryptionManager is implemented by backends that support default bucket encryption
+type EncryptionManager interface {
+	// GetBucketEncryption returns the default encryption of the bucket, or nil if it has none
+	GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error)
+	// PutBucketEncryption sets the default encryption of the bucket. Backends that do not
+	// support the configuration return an error wrapping ErrEncryptionRejected.
+	PutBucketEncryption(ctx context.Context, bucketName string, encryption BucketEncryption) error
+}
+
+// Server-side encryption algorithms
+const (
+	// SSEAlgorithmAES256 encrypts objects with keys managed by the backend (SSE-S3)
+	SSEAlgorithmAES256 = "AES256"
+	// SSEAlgorithmKMS encrypts objects with a key from the backend's KMS (SSE-KMS)
+	SSEAlgorithmKMS = "aws:kms"
+)
+
+// BucketEncryption is the default server-side encryption of a bucket
+type BucketEncryption struct {
+	// Algorithm is SSEAlgorithmAES256 or SSEAlgorithmKMS
+	Algorithm string
+	// KMSKeyID is the KMS key used with SSEAlgorithmKMS
+	KMSKeyID string
+}
+
+// CORSManager is implemented by backends that support S3 bucket CORS configuration
+type CORSManager interface {
+	// GetBucketCors returns the CORS rules of the bucket, or nil if it has none
+	GetBucketCors(ctx context.Context, bucketName string) ([]CORSRule, error)
+	PutBucketCors(ctx context.Context, bucketName string, rules []CORSRule) error
+	DeleteBucketCors(ctx context.Context, bucketName string) error
+}
+
+// CORSRule is a bucket CORS rule
+type CORSRule struct {
+	ID             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	// MaxAgeSeconds is how long browsers may cache the preflight response; zero leaves it out
+	MaxAgeSeconds int `json:"maxAgeSeconds,omitempty"`
+}
+
+// BucketQuotaManager is implemented by backends that can cap the size of a bucket
+type BucketQuotaManager interface {
+	// GetBucketQuota returns the quota of the bucket; a zero quota means unlimited
+	GetBucketQuota(ctx context.Context, bucketName string) (BucketQuota, error)
+	// SetBucketQuota sets the quota of the bucket; a zero quota removes it
+	SetBucketQuota(ctx context.Context, bucketName string, quota BucketQuota) error
+	// SupportsObjectQuota reports whether SetBucketQuota can cap the number of objects;
+	// quota managers that cannot ignore MaxObjects
+	SupportsObjectQuota() bool
+}
+
+// BucketQuota caps the size of a bucket. Zero values mean unlimited.
+type BucketQuota struct {
+	// MaxSize is the maximum total size of the objects in bytes
+	MaxSize int64
+	// MaxObjects is the maximum number of objects
+	MaxObjects int64
+}
+
+// IsZero reports whether the quota is unlimited
+func (q BucketQuota) IsZero() bool {
+	return q.MaxSize == 0 && q.MaxObjects == 0
+}
+
+// CredentialsVerifier is implemented by backends that can check a user's secret key
+type CredentialsVerifier interface {
+	// VerifyCredentials reports whether the backend accepts the key pair
+	VerifyCredentials(ctx context.Context, accessKey, secretKey string) (bool, error)
+}
+
 // Config holds common backend configuration
 type Config struct {
 	EndpointURL string
 	AccessKey   string
 	SecretKey   string
+
+	// AdminEndpointURL and AdminToken configure backends whose admin API is
+	// served separately from the S3 endpoint (Garage)
+	AdminEndpointURL string
+	AdminToken       string
 }
 
 // NewBackend creates a new backend instance based on the backend name
@@ -45,6 +200,23 @@ func NewBackend(name string, config Config) (Backend, error) {
 	}
 }
 
+var (
+	// ErrBucketNotEmpty is returned by DeleteBucket when the bucket still contains objects
+	ErrBucketNotEmpty = errors.New("bucket is not empty")
+
+	// ErrBucketOwnedByOtherAccount is returned by BucketExists when the bucket exists but
+	// the operator's credentials are denied access to it
+	ErrBucketOwnedByOtherAccount = errors.New("bucket is owned by another account")
+
+	// ErrEncryptionRejected is returned by EncryptionManager methods when the backend does not
+	// support the requested bucket encryption, e.g. because it has no KMS configured
+	ErrEncryptionRejected = errors.New("backend rejected the bucket encryption configuration")
+
+	// ErrSecretKeyChangeUnsupported is returned by UpdateUser when the backend cannot change the
+	// secret key of an existing user
+	ErrSecretKeyChangeUnsupported = errors.New("backend cannot change the secret key of an existing user")
+)
+
 // ErrUnsupportedBackend is returned when an unknown backend is requested
 type ErrUnsupportedBackend struct {
 	Backend string
@@ -2,7 +2,15 @@ package backends
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -95,10 +103,10 @@ func TestMinIONotImplemented(t *testing.T) {
 
 	backend := NewMinIO(config)
 
-	// Test operations that should return "not implemented"
-	err := backend.CreateUser(ctx, "user", "pass", nil, nil, nil)
+	// MinIO has no bucket ownership concept
+	_, err := backend.GetBucketOwner(ctx, "bucket")
 	if err == nil {
-		t.Error("expected error for CreateUser, got nil")
+		t.Error("expected error for GetBucketOwner, got nil")
 	}
 
 	err = backend.ChangeBucketOwner(ctx, "bucket", "owner")
@@ -107,7 +115,7 @@ func TestMinIONotImplemented(t *testing.T) {
 	}
 }
 
-func TestGarageNotImplemented(t *testing.T) {
+func TestGarageAdminNotConfigured(t *testing.T) {
 	ctx := context.Background()
 	config := Config{
 		EndpointURL: "http://localhost:3900",
@@ -117,7 +125,7 @@ func TestGarageNotImplemented(t *testing.T) {
 
 	backend := NewGarage(config)
 
-	// Test operations that should return "not implemented"
+	// User and ownership operations need the admin API
 	err := backend.CreateUser(ctx, "user", "pass", nil, nil, nil)
 	if err == nil {
 		t.Error("expected error for CreateUser, got nil")
@@ -363,3 +371,660 @@ func TestMockBackend_WithOptionalFields(t *testing.T) {
 		t.Errorf("expected updated groupID 2001, got %d", *user.GroupID)
 	}
 }
+
+func TestMockBackend_EmptyBucket(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockBackend("http://localhost:9000")
+
+	if err := mock.CreateBucket(ctx, "bucket1", nil); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	mock.NonEmptyBuckets["bucket1"] = true
+
+	err := mock.DeleteBucket(ctx, "bucket1")
+	if !errors.Is(err, ErrBucketNotEmpty) {
+		t.Fatalf("expected ErrBucketNotEmpty, got %v", err)
+	}
+
+	if err := mock.EmptyBucket(ctx, "bucket1"); err != nil {
+		t.Fatalf("EmptyBucket failed: %v", err)
+	}
+	if err := mock.DeleteBucket(ctx, "bucket1"); err != nil {
+		t.Fatalf("DeleteBucket failed after emptying: %v", err)
+	}
+}
+
+func TestMergeBucketPolicy(t *testing.T) {
+	foreign := `{"Version":"2012-10-17","Statement":[{"Sid":"Foreign","Effect":"Allow","Principal":{"AWS":["other"]},"Action":"s3:GetObject","Resource":"arn:aws:s3:::data/*"}]}`
+
+	merged, err := MergeBucketPolicy(foreign, "data", "app-user", BucketPolicyReadOnly)
+	if err != nil {
+		t.Fatalf("MergeBucketPolicy failed: %v", err)
+	}
+	if !strings.Contains(merged, `"Sid":"Foreign"`) {
+		t.Error("expected foreign statement to be kept")
+	}
+	if !HasBucketPolicyStatement(merged, "app-user") {
+		t.Error("expected generated statement for app-user")
+	}
+	if strings.Contains(merged, "s3:PutObject") {
+		t.Error("did not expect write actions in read-only policy")
+	}
+
+	// Switching presets replaces the statement instead of adding a second one
+	merged, err = MergeBucketPolicy(merged, "data", "app-user", BucketPolicyReadWrite)
+	if err != nil {
+		t.Fatalf("MergeBucketPolicy failed: %v", err)
+	}
+	if strings.Count(merged, BucketPolicyStatementID("app-user")) != 1 {
+		t.Errorf("expected exactly one statement for app-user, got policy %s", merged)
+	}
+	if !strings.Contains(merged, "s3:PutObject") {
+		t.Error("expected write actions in read-write policy")
+	}
+
+	// Removing the statement keeps the foreign one
+	merged, err = MergeBucketPolicy(merged, "data", "app-user", "")
+	if err != nil {
+		t.Fatalf("MergeBucketPolicy failed: %v", err)
+	}
+	if HasBucketPolicyStatement(merged, "app-user") {
+		t.Error("expected statement for app-user to be removed")
+	}
+	if !strings.Contains(merged, `"Sid":"Foreign"`) {
+		t.Error("expected foreign statement to be kept")
+	}
+
+	// Removing the only statement yields an empty policy
+	own, _ := MergeBucketPolicy("", "data", "app-user", BucketPolicyPublicRead)
+	if !strings.Contains(own, `"*"`) {
+		t.Errorf("expected anonymous principal in public-read policy, got %s", own)
+	}
+	empty, err := MergeBucketPolicy(own, "data", "app-user", "")
+	if err != nil {
+		t.Fatalf("MergeBucketPolicy failed: %v", err)
+	}
+	if empty != "" {
+		t.Errorf("expected empty policy, got %s", empty)
+	}
+
+	if _, err := MergeBucketPolicy("not json", "data", "app-user", BucketPolicyReadOnly); err == nil {
+		t.Error("expected error for invalid existing policy")
+	}
+}
+
+func TestParseBucketPolicyPreset(t *testing.T) {
+	for _, valid := range []string{"read-only", "read-write", "public-read"} {
+		if _, err := ParseBucketPolicyPreset(valid); err != nil {
+			t.Errorf("unexpected error for %q: %v", valid, err)
+		}
+	}
+	if _, err := ParseBucketPolicyPreset("write-only"); err == nil {
+		t.Error("expected error for invalid preset")
+	}
+}
+
+func TestBackendCapabilities(t *testing.T) {
+	config := Config{EndpointURL: "http://localhost:9000"}
+
+	tests := []struct {
+		name           string
+		backend        Backend
+		bucketPolicies bool
+		versioning     bool
+		lifecycle      bool
+		quota          bool
+		cors           bool
+		tagging        bool
+	}{
+		{"versitygw", NewVersityGW(config), true, true, false, false, true, true},
+		{"minio", NewMinIO(config), true, true, true, true, true, true},
+		{"garage", NewGarage(config), false, false, true, true, true, false},
+		{"mock", NewMockBackend(config.EndpointURL), true, true, true, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.backend.(BucketPolicyManager); ok != tt.bucketPolicies {
+				t.Errorf("expected BucketPolicyManager=%v, got %v", tt.bucketPolicies, ok)
+			}
+			if _, ok := tt.backend.(BucketVersioningManager); ok != tt.versioning {
+				t.Errorf("expected BucketVersioningManager=%v, got %v", tt.versioning, ok)
+			}
+			if _, ok := tt.backend.(ObjectLockManager); ok != tt.versioning {
+				t.Errorf("expected ObjectLockManager=%v, got %v", tt.versioning, ok)
+			}
+			if _, ok := tt.backend.(LifecycleManager); ok != tt.lifecycle {
+				t.Errorf("expected LifecycleManager=%v, got %v", tt.lifecycle, ok)
+			}
+			if _, ok := tt.backend.(BucketQuotaManager); ok != tt.quota {
+				t.Errorf("expected BucketQuotaManager=%v, got %v", tt.quota, ok)
+			}
+			if _, ok := tt.backend.(EncryptionManager); !ok {
+				t.Error("expected EncryptionManager to be implemented")
+			}
+			if _, ok := tt.backend.(BucketTaggingManager); ok != tt.tagging {
+				t.Errorf("expected BucketTaggingManager=%v, got %v", tt.tagging, ok)
+			}
+			if _, ok := tt.backend.(CORSManager); ok != tt.cors {
+				t.Errorf("expected CORSManager=%v, got %v", tt.cors, ok)
+			}
+			if _, ok := tt.backend.(CredentialsVerifier); !ok {
+				t.Error("expected CredentialsVerifier to be implemented")
+			}
+		})
+	}
+}
+
+func TestVerifyS3Credentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		code      string
+		wantValid bool
+		wantErr   bool
+	}{
+		{name: "accepted", status: http.StatusOK, wantValid: true},
+		{name: "access denied", status: http.StatusForbidden, code: "AccessDenied", wantValid: true},
+		{name: "unknown access key", status: http.StatusForbidden, code: "InvalidAccessKeyId"},
+		{name: "wrong secret key", status: http.StatusForbidden, code: "SignatureDoesNotMatch"},
+		{name: "server error", status: http.StatusInternalServerError, code: "InternalError", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(tt.status)
+				if tt.code == "" {
+					fmt.Fprint(w, `<ListAllMyBucketsResult><Buckets></Buckets></ListAllMyBucketsResult>`)
+					return
+				}
+				fmt.Fprintf(w, `<Error><Code>%s</Code><Message>test</Message></Error>`, tt.code)
+			}))
+			defer server.Close()
+
+			valid, err := verifyS3Credentials(context.Background(), server.URL, "user", "secret")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if valid != tt.wantValid {
+				t.Errorf("expected valid=%v, got %v", tt.wantValid, valid)
+			}
+		})
+	}
+}
+
+func TestGenerateCredentials(t *testing.T) {
+	config := Config{EndpointURL: "http://localhost:9000"}
+
+	tests := []struct {
+		name            string
+		backend         Backend
+		accessKeyPrefix string
+		accessKeyLength int
+		secretKeyLength int
+	}{
+		{"versitygw", NewVersityGW(config), "", 20, 40},
+		{"minio", NewMinIO(config), "", 20, 40},
+		{"garage", NewGarage(config), "GK", 26, 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessKey, err := GenerateAccessKey(tt.backend)
+			if err != nil {
+				t.Fatalf("GenerateAccessKey failed: %v", err)
+			}
+			secretKey, err := GenerateSecretKey(tt.backend)
+			if err != nil {
+				t.Fatalf("GenerateSecretKey failed: %v", err)
+			}
+
+			if len(accessKey) != tt.accessKeyLength || !strings.HasPrefix(accessKey, tt.accessKeyPrefix) {
+				t.Errorf("unexpected access key format %q", accessKey)
+			}
+			if len(secretKey) != tt.secretKeyLength {
+				t.Errorf("expected secret key of length %d, got %d", tt.secretKeyLength, len(secretKey))
+			}
+
+			other, _ := GenerateSecretKey(tt.backend)
+			if other == secretKey {
+				t.Error("expected generated secret keys to differ")
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	if registry.Default() != nil {
+		t.Fatal("expected empty registry to have no default backend")
+	}
+
+	first := NewMockBackend("http://first:9000")
+	second := NewMockBackend("http://second:9000/")
+	if err := registry.Register("first", first, false); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register("second", second, false); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if registry.Default() != first {
+		t.Error("expected the first backend to be the default")
+	}
+	if backend, ok := registry.Get("second"); !ok || backend != second {
+		t.Error("expected to find the second backend by name")
+	}
+	if _, ok := registry.Get("unknown"); ok {
+		t.Error("did not expect to find an unknown backend")
+	}
+	if backend, ok := registry.ForEndpoint("http://second:9000"); !ok || backend != second {
+		t.Error("expected to find the second backend by endpoint, ignoring the trailing slash")
+	}
+	if _, ok := registry.ForEndpoint("http://third:9000"); ok {
+		t.Error("did not expect to find a backend for an unknown endpoint")
+	}
+
+	if err := registry.Register("first", NewMockBackend("http://other:9000"), false); err == nil {
+		t.Error("expected error for duplicate backend name")
+	}
+	if err := registry.Register("third", NewMockBackend("http://first:9000"), false); err == nil {
+		t.Error("expected error for duplicate endpoint")
+	}
+	if err := registry.Register("fourth", NewMockBackend("http://fourth:9000"), true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, ok := registry.Get("fourth"); !ok || registry.Default() == first {
+		t.Error("expected the backend marked as default to become the default")
+	}
+
+	var empty *Registry
+	if _, ok := empty.Get("first"); ok {
+		t.Error("did not expect a nil registry to contain backends")
+	}
+}
+
+func TestLoadRegistryConfig(t *testing.T) {
+	path := t.TempDir() + "/backends.yaml"
+	content := `backends:
+  - name: minio
+    type: minio
+    endpointURL: http://minio:9000
+    accessKey: admin
+    secretKey: secret
+  - name: garage
+    type: garage
+    endpointURL: http://garage:3900
+    accessKey: GKadmin
+    secretKey: secret
+    adminEndpointURL: http://garage:3903
+    adminToken: token
+    default: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := LoadRegistryConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRegistryConfig failed: %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 backends, got %d", len(configs))
+	}
+
+	registry := NewRegistry()
+	for _, config := range configs {
+		if err := registry.Add(config); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if registry.Default().GetEndpointURL() != "http://garage:3900" {
+		t.Errorf("expected garage to be the default backend, got %s", registry.Default().GetEndpointURL())
+	}
+
+	if err := registry.Add(BackendConfig{Name: "incomplete", Type: "garage", EndpointURL: "http://other:3900", AccessKey: "a", SecretKey: "b"}); err == nil {
+		t.Error("expected error for garage backend without admin configuration")
+	}
+
+	if err := os.WriteFile(path, []byte("backends:\n  - name: x\n    endpoint: http://x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRegistryConfig(path); err == nil {
+		t.Error("expected error for unknown configuration field")
+	}
+}
+
+func TestS3BucketExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		closed     bool
+		wantExists bool
+		wantErr    error
+	}{
+		{name: "exists", status: http.StatusOK, wantExists: true},
+		{name: "not found", status: http.StatusNotFound},
+		{name: "forbidden", status: http.StatusForbidden, wantErr: ErrBucketOwnedByOtherAccount},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: errAny},
+		{name: "transport error", closed: true, wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			if tt.closed {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+
+			candidates := []Backend{
+				NewVersityGW(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+				NewMinIO(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+				NewGarage(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+			}
+			for _, backend := range candidates {
+				exists, err := backend.BucketExists(context.Background(), "test-bucket")
+				switch {
+				case tt.wantErr == nil && err != nil:
+					t.Errorf("%T: unexpected error: %v", backend, err)
+				case tt.wantErr == errAny && err == nil:
+					t.Errorf("%T: expected error, got nil", backend)
+				case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+					t.Errorf("%T: expected %v, got %v", backend, tt.wantErr, err)
+				}
+				if exists != tt.wantExists {
+					t.Errorf("%T: expected exists=%v, got %v", backend, tt.wantExists, exists)
+				}
+			}
+		})
+	}
+}
+
+func TestS3EmptyBucket(t *testing.T) {
+	tests := []struct {
+		name      string
+		failKey   string
+		expectErr bool
+	}{
+		{name: "all objects deleted"},
+		{name: "object refused", failKey: "b", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  sync.Mutex
+				log []string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				query := r.URL.Query()
+				switch {
+				case r.Method == http.MethodGet && query.Has("versions"):
+					// Two listing pages of one version each
+					if query.Get("key-marker") == "" {
+						log = append(log, "list a")
+						_, _ = w.Write([]byte(`<ListVersionsResult><IsTruncated>true</IsTruncated><NextKeyMarker>a</NextKeyMarker><NextVersionIdMarker>1</NextVersionIdMarker><Version><Key>a</Key><VersionId>1</VersionId></Version></ListVersionsResult>`))
+						return
+					}
+					log = append(log, "list b")
+					_, _ = w.Write([]byte(`<ListVersionsResult><IsTruncated>false</IsTruncated><Version><Key>b</Key><VersionId>2</VersionId></Version></ListVersionsResult>`))
+				case r.Method == http.MethodPost && query.Has("delete"):
+					body, _ := io.ReadAll(r.Body)
+					for _, key := range []string{"a", "b"} {
+						if !strings.Contains(string(body), "<Key>"+key+"</Key>") {
+							continue
+						}
+						log = append(log, "delete "+key)
+						if key == tt.failKey {
+							_, _ = w.Write([]byte(`<DeleteResult><Error><Key>` + key + `</Key><VersionId>2</VersionId><Code>AccessDenied</Code><Message>Object is locked</Message></Error></DeleteResult>`))
+							return
+						}
+					}
+					_, _ = w.Write([]byte(`<DeleteResult></DeleteResult>`))
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+
+			backend := NewMinIO(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"})
+			err := backend.EmptyBucket(context.Background(), "test-bucket")
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error=%v, got %v", tt.expectErr, err)
+			}
+
+			// Each page is deleted before the next one is listed
+			expected := []string{"list a", "delete a", "list b", "delete b"}
+			if !reflect.DeepEqual(log, expected) {
+				t.Errorf("expected requests %v, got %v", expected, log)
+			}
+		})
+	}
+}
+
+// errAny matches any non-nil error in table tests
+var errAny = errors.New("any error")
+
+func TestS3BucketCors(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		stored []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, ok := r.URL.Query()["cors"]; !ok || r.URL.Path != "/test-bucket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<Error><Code>NoSuchCORSConfiguration</Code></Error>`))
+				return
+			}
+			_, _ = w.Write(stored)
+		case http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+		case http.MethodDelete:
+			stored = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	candidates := []Backend{
+		NewVersityGW(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+		NewMinIO(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+		NewGarage(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+	}
+	rules := []CORSRule{
+		{
+			ID:             "frontend",
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: []string{"GET", "PUT"},
+			AllowedHeaders: []string{"*"},
+			ExposeHeaders:  []string{"ETag"},
+			MaxAgeSeconds:  3600,
+		},
+		{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, AllowedHeaders: []string{}, ExposeHeaders: []string{}},
+	}
+
+	for _, backend := range candidates {
+		manager := backend.(CORSManager)
+
+		got, err := manager.GetBucketCors(ctx, "test-bucket")
+		if err != nil || got != nil {
+			t.Fatalf("%T: expected no CORS rules, got %+v, %v", backend, got, err)
+		}
+
+		if err := manager.PutBucketCors(ctx, "test-bucket", rules); err != nil {
+			t.Fatalf("%T: PutBucketCors failed: %v", backend, err)
+		}
+		got, err = manager.GetBucketCors(ctx, "test-bucket")
+		if err != nil {
+			t.Fatalf("%T: GetBucketCors failed: %v", backend, err)
+		}
+		if !reflect.DeepEqual(got, rules) {
+			t.Errorf("%T: expected %+v, got %+v", backend, rules, got)
+		}
+
+		if err := manager.DeleteBucketCors(ctx, "test-bucket"); err != nil {
+			t.Fatalf("%T: DeleteBucketCors failed: %v", backend, err)
+		}
+		if got, err := manager.GetBucketCors(ctx, "test-bucket"); err != nil || got != nil {
+			t.Errorf("%T: expected CORS rules to be removed, got %+v, %v", backend, got, err)
+		}
+	}
+}
+
+func TestS3BucketEncryption(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		code         string
+		wantRejected bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "not implemented", status: http.StatusNotImplemented, code: "NotImplemented", wantRejected: true},
+		{name: "kms not configured", status: http.StatusBadRequest, code: "XMinioKMSNotConfigured", wantRejected: true},
+		{name: "unknown kms key", status: http.StatusBadRequest, code: "KMS.NotFoundException", wantRejected: true},
+		{name: "access denied", status: http.StatusForbidden, code: "AccessDenied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				stored []byte
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if _, ok := r.URL.Query()["encryption"]; !ok {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if tt.code != "" {
+					w.WriteHeader(tt.status)
+					_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>rejected</Message></Error>", tt.code)
+					return
+				}
+				switch r.Method {
+				case http.MethodGet:
+					if stored == nil {
+						w.WriteHeader(http.StatusNotFound)
+						_, _ = w.Write([]byte(`<Error><Code>ServerSideEncryptionConfigurationNotFoundError</Code></Error>`))
+						return
+					}
+					_, _ = w.Write(stored)
+				case http.MethodPut:
+					stored, _ = io.ReadAll(r.Body)
+				}
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			candidates := []Backend{
+				NewVersityGW(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+				NewMinIO(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+				NewGarage(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+			}
+			encryption := BucketEncryption{Algorithm: SSEAlgorithmKMS, KMSKeyID: "my-key"}
+
+			for _, backend := range candidates {
+				manager := backend.(EncryptionManager)
+				err := manager.PutBucketEncryption(ctx, "test-bucket", encryption)
+				if tt.code != "" {
+					if err == nil {
+						t.Fatalf("%T: expected error, got nil", backend)
+					}
+					if errors.Is(err, ErrEncryptionRejected) != tt.wantRejected {
+						t.Errorf("%T: expected ErrEncryptionRejected=%v, got %v", backend, tt.wantRejected, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%T: PutBucketEncryption failed: %v", backend, err)
+				}
+				got, err := manager.GetBucketEncryption(ctx, "test-bucket")
+				if err != nil {
+					t.Fatalf("%T: GetBucketEncryption failed: %v", backend, err)
+				}
+				if got == nil || *got != encryption {
+					t.Errorf("%T: expected %+v, got %+v", backend, encryption, got)
+				}
+				stored = nil
+			}
+		})
+	}
+}
+
+func TestS3BucketTagging(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		stored []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, ok := r.URL.Query()["tagging"]; !ok || r.URL.Path != "/test-bucket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<Error><Code>NoSuchTagSet</Code></Error>`))
+				return
+			}
+			_, _ = w.Write(stored)
+		case http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	candidates := []Backend{
+		NewVersityGW(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+		NewMinIO(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "secret"}),
+	}
+	tags := map[string]string{"s3-resource-operator.io/namespace": "team-a", "cost-center": "42 & more"}
+
+	for _, backend := range candidates {
+		stored = nil
+		manager := backend.(BucketTaggingManager)
+
+		got, err := manager.GetBucketTagging(ctx, "test-bucket")
+		if err != nil || len(got) != 0 {
+			t.Fatalf("%T: expected no tags, got %v, %v", backend, got, err)
+		}
+
+		if err := manager.PutBucketTagging(ctx, "test-bucket", tags); err != nil {
+			t.Fatalf("%T: PutBucketTagging failed: %v", backend, err)
+		}
+		got, err = manager.GetBucketTagging(ctx, "test-bucket")
+		if err != nil {
+			t.Fatalf("%T: GetBucketTagging failed: %v", backend, err)
+		}
+		if !reflect.DeepEqual(got, tags) {
+			t.Errorf("%T: expected %v, got %v", backend, tags, got)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package backends
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+)
+
+const (
+	accessKeyAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	secretKeyAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+	accessKeyLength = 20
+	secretKeyLength = 40
+)
+
+// CredentialsGenerator is implemented by backends that only accept keys in a specific format
+type CredentialsGenerator interface {
+	GenerateAccessKey() (string, error)
+	GenerateSecretKey() (string, error)
+}
+
+// GenerateAccessKey returns a random access key in a format accepted by the backend
+func GenerateAccessKey(backend Backend) (string, error) {
+	if generator, ok := backend.(CredentialsGenerator); ok {
+		return generator.GenerateAccessKey()
+	}
+	return randomString(accessKeyAlphabet, accessKeyLength)
+}
+
+// GenerateSecretKey returns a random secret key in a format accepted by the backend
+func GenerateSecretKey(backend Backend) (string, error) {
+	if generator, ok := backend.(CredentialsGenerator); ok {
+		return generator.GenerateSecretKey()
+	}
+	return randomString(secretKeyAlphabet, secretKeyLength)
+}
+
+// randomString returns a cryptographically random string of length characters from alphabet
+func randomString(alphabet string, length int) (string, error) {
+	limit := big.NewInt(int64(len(alphabet)))
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// randomHex returns n cryptographically random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
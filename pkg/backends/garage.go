@@ -2,7 +2,11 @@ package backends
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -11,12 +15,16 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-// Garage implements the Backend interface for Garage
+// Garage implements the Backend interface for Garage.
+// Users are Garage access keys managed through the admin API, which runs on a separate port.
 type Garage struct {
-	endpointURL string
-	accessKey   string
-	secretKey   string
-	s3Client    *s3.S3
+	endpointURL      string
+	accessKey        string
+	secretKey        string
+	adminEndpointURL string
+	adminToken       string
+	s3Client         *s3.S3
+	httpClient       *http.Client
 }
 
 // NewGarage creates a new Garage backend
@@ -29,10 +37,13 @@ func NewGarage(config Config) *Garage {
 	}))
 
 	return &Garage{
-		endpointURL: config.EndpointURL,
-		accessKey:   config.AccessKey,
-		secretKey:   config.SecretKey,
-		s3Client:    s3.New(sess),
+		endpointURL:      config.EndpointURL,
+		accessKey:        config.AccessKey,
+		secretKey:        config.SecretKey,
+		adminEndpointURL: config.AdminEndpointURL,
+		adminToken:       config.AdminToken,
+		s3Client:         s3.New(sess),
+		httpClient:       &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
@@ -50,53 +61,217 @@ func (g *Garage) TestConnection(ctx context.Context) error {
 	}
 	log.Info("Successfully listed buckets", "count", len(result.Buckets))
 
+	if err := g.adminRequest(ctx, http.MethodGet, "/v1/status", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to reach admin API: %w", err)
+	}
+	log.Info("Successfully reached admin API", "endpoint", g.adminEndpointURL)
+
 	return nil
 }
 
 func (g *Garage) CreateBucket(ctx context.Context, bucketName string, owner *string) error {
-	_, err := g.s3Client.CreateBucketWithContext(ctx, &s3.CreateBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	return err
+	if err := createS3Bucket(ctx, g.s3Client, bucketName, false); err != nil {
+		return err
+	}
+
+	if owner != nil {
+		return g.ChangeBucketOwner(ctx, bucketName, *owner)
+	}
+
+	return nil
 }
 
 func (g *Garage) DeleteBucket(ctx context.Context, bucketName string) error {
-	_, err := g.s3Client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	return err
+	return deleteS3Bucket(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) EmptyBucket(ctx context.Context, bucketName string) error {
+	return emptyS3Bucket(ctx, g.s3Client, bucketName)
 }
 
 func (g *Garage) BucketExists(ctx context.Context, bucketName string) (bool, error) {
-	_, err := g.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
-		return false, nil
-	}
-	return true, nil
+	return s3BucketExists(ctx, g.s3Client, bucketName)
 }
 
+// GetBucketOwner returns the first access key holding the owner permission on the bucket
 func (g *Garage) GetBucketOwner(ctx context.Context, bucketName string) (string, error) {
-	return "", fmt.Errorf("not implemented for Garage")
+	bucket, err := g.getBucket(ctx, bucketName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get bucket info: %w", err)
+	}
+
+	for _, key := range bucket.Keys {
+		if key.Permissions.Owner {
+			return key.AccessKeyID, nil
+		}
+	}
+	return "", nil
 }
 
+// ChangeBucketOwner grants read, write and owner permissions to newOwner and
+// revokes them from every other access key that currently owns the bucket
 func (g *Garage) ChangeBucketOwner(ctx context.Context, bucketName, newOwner string) error {
-	return fmt.Errorf("not implemented for Garage")
+	bucket, err := g.getBucket(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket info: %w", err)
+	}
+
+	full := garagePermissions{Read: true, Write: true, Owner: true}
+	if err := g.setBucketPermissions(ctx, bucket.ID, newOwner, full, true); err != nil {
+		return fmt.Errorf("failed to grant bucket permissions: %w", err)
+	}
+
+	for _, key := range bucket.Keys {
+		if key.AccessKeyID == newOwner || !key.Permissions.Owner {
+			continue
+		}
+		if err := g.setBucketPermissions(ctx, bucket.ID, key.AccessKeyID, full, false); err != nil {
+			return fmt.Errorf("failed to revoke bucket permissions from %s: %w", key.AccessKeyID, err)
+		}
+	}
+
+	ctrl.Log.WithName("garage").Info("Changed bucket owner", "bucket", bucketName, "newOwner", newOwner)
+	return nil
 }
 
+// CreateUser imports an access key. Garage requires key IDs of the form GK followed by
+// 24 hex characters and 64 hex character secrets. Role, user and group IDs are not supported.
 func (g *Garage) CreateUser(ctx context.Context, accessKey, secretKey string, role *string, userID, groupID *int) error {
-	return fmt.Errorf("not implemented for Garage")
+	if err := g.importKey(ctx, accessKey, secretKey); err != nil {
+		return fmt.Errorf("failed to import key: %w", err)
+	}
+
+	ctrl.Log.WithName("garage").Info("Created user", "user", accessKey)
+	return nil
 }
 
 func (g *Garage) DeleteUser(ctx context.Context, accessKey string) error {
-	return fmt.Errorf("not implemented for Garage")
+	if err := g.adminRequest(ctx, http.MethodDelete, "/v1/key", url.Values{"id": {accessKey}}, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+
+	ctrl.Log.WithName("garage").Info("Deleted user", "user", accessKey)
+	return nil
 }
 
+// UpdateUser only accepts the current secret of an access key. Garage cannot change the secret
+// of an existing key and never imports a key ID again once it was deleted, so a different secret
+// fails with ErrSecretKeyChangeUnsupported and leaves the key unchanged.
 func (g *Garage) UpdateUser(ctx context.Context, accessKey string, secretKey *string, userID, groupID *int) error {
-	return fmt.Errorf("not implemented for Garage")
+	if secretKey == nil {
+		return nil
+	}
+
+	key, err := g.getKey(ctx, accessKey, true)
+	if err != nil {
+		return fmt.Errorf("failed to get key: %w", err)
+	}
+	if key.SecretAccessKey != nil && *key.SecretAccessKey == *secretKey {
+		return nil
+	}
+	return fmt.Errorf("%w: garage cannot change the secret of key %s, create a new access key instead", ErrSecretKeyChangeUnsupported, accessKey)
 }
 
 func (g *Garage) UserExists(ctx context.Context, accessKey string) (bool, error) {
-	return false, fmt.Errorf("not implemented for Garage")
+	_, err := g.getKey(ctx, accessKey, false)
+	if errors.Is(err, errGarageNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get key: %w", err)
+	}
+	return true, nil
+}
+
+func (g *Garage) VerifyCredentials(ctx context.Context, accessKey, secretKey string) (bool, error) {
+	return verifyS3Credentials(ctx, g.endpointURL, accessKey, secretKey)
+}
+
+// GenerateAccessKey returns a key ID in Garage's format: "GK" followed by 24 hex characters
+func (g *Garage) GenerateAccessKey() (string, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return "", err
+	}
+	return "GK" + id, nil
+}
+
+// GenerateSecretKey returns a secret key in Garage's format: 64 hex characters
+func (g *Garage) GenerateSecretKey() (string, error) {
+	return randomHex(32)
+}
+
+func (g *Garage) GetBucketLifecycle(ctx context.Context, bucketName string) ([]LifecycleRule, error) {
+	return getS3BucketLifecycle(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) PutBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) error {
+	return putS3BucketLifecycle(ctx, g.s3Client, bucketName, rules)
+}
+
+func (g *Garage) DeleteBucketLifecycle(ctx context.Context, bucketName string) error {
+	return deleteS3BucketLifecycle(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) GetBucketQuota(ctx context.Context, bucketName string) (BucketQuota, error) {
+	bucket, err := g.getBucket(ctx, bucketName)
+	if err != nil {
+		return BucketQuota{}, fmt.Errorf("failed to get bucket info: %w", err)
+	}
+
+	var quota BucketQuota
+	if bucket.Quotas.MaxSize != nil {
+		quota.MaxSize = *bucket.Quotas.MaxSize
+	}
+	if bucket.Quotas.MaxObjects != nil {
+		quota.MaxObjects = *bucket.Quotas.MaxObjects
+	}
+	return quota, nil
+}
+
+// SetBucketQuota sets the size and object count quotas of a bucket; zero values remove them
+func (g *Garage) SetBucketQuota(ctx context.Context, bucketName string, quota BucketQuota) error {
+	bucket, err := g.getBucket(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket info: %w", err)
+	}
+
+	var quotas garageBucketQuotas
+	if quota.MaxSize > 0 {
+		quotas.MaxSize = &quota.MaxSize
+	}
+	if quota.MaxObjects > 0 {
+		quotas.MaxObjects = &quota.MaxObjects
+	}
+	if err := g.updateBucketQuotas(ctx, bucket.ID, quotas); err != nil {
+		return fmt.Errorf("failed to update bucket quotas: %w", err)
+	}
+
+	ctrl.Log.WithName("garage").Info("Set bucket quota", "bucket", bucketName, "maxSize", quota.MaxSize, "maxObjects", quota.MaxObjects)
+	return nil
+}
+
+// SupportsObjectQuota returns true, Garage quotas can limit the number of objects
+func (g *Garage) SupportsObjectQuota() bool {
+	return true
+}
+
+func (g *Garage) GetBucketCors(ctx context.Context, bucketName string) ([]CORSRule, error) {
+	return getS3BucketCors(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) PutBucketCors(ctx context.Context, bucketName string, rules []CORSRule) error {
+	return putS3BucketCors(ctx, g.s3Client, bucketName, rules)
+}
+
+func (g *Garage) DeleteBucketCors(ctx context.Context, bucketName string) error {
+	return deleteS3BucketCors(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error) {
+	return getS3BucketEncryption(ctx, g.s3Client, bucketName)
+}
+
+func (g *Garage) PutBucketEncryption(ctx context.Context, bucketName string, encryption BucketEncryption) error {
+	return putS3BucketEncryption(ctx, g.s3Client, bucketName, encryption)
 }
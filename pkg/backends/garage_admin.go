@@ -0,0 +1,185 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errGarageNotFound is returned by the admin API when a key or bucket does not exist
+var errGarageNotFound = errors.New("garage: not found")
+
+// garagePermissions are the per-bucket permissions of an access key
+type garagePermissions struct {
+	Read  bool `json:"read"`
+	Write bool `json:"write"`
+	Owner bool `json:"owner"`
+}
+
+// garageKeyBucket is a bucket entry of a key info response
+type garageKeyBucket struct {
+	ID            string            `json:"id"`
+	GlobalAliases []string          `json:"globalAliases"`
+	Permissions   garagePermissions `json:"permissions"`
+}
+
+// garageKeyInfo is the response of the key info call
+type garageKeyInfo struct {
+	Name            string            `json:"name"`
+	AccessKeyID     string            `json:"accessKeyId"`
+	SecretAccessKey *string           `json:"secretAccessKey"`
+	Buckets         []garageKeyBucket `json:"buckets"`
+}
+
+// garageBucketKey is a key entry of a bucket info response
+type garageBucketKey struct {
+	AccessKeyID string            `json:"accessKeyId"`
+	Name        string            `json:"name"`
+	Permissions garagePermissions `json:"permissions"`
+}
+
+// garageBucketQuotas are the quotas of a bucket; nil means unlimited
+type garageBucketQuotas struct {
+	MaxSize    *int64 `json:"maxSize"`
+	MaxObjects *int64 `json:"maxObjects"`
+}
+
+// garageBucketInfo is the response of the bucket info call
+type garageBucketInfo struct {
+	ID            string             `json:"id"`
+	GlobalAliases []string           `json:"globalAliases"`
+	Keys          []garageBucketKey  `json:"keys"`
+	Quotas        garageBucketQuotas `json:"quotas"`
+}
+
+// garageUpdateBucketRequest is the payload of the bucket update call
+type garageUpdateBucketRequest struct {
+	Quotas *garageBucketQuotas `json:"quotas,omitempty"`
+}
+
+// garageImportKeyRequest is the payload of the key import call
+type garageImportKeyRequest struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	Name            string `json:"name"`
+}
+
+// garageBucketPermissionRequest is the payload of the bucket allow and deny calls
+type garageBucketPermissionRequest struct {
+	BucketID    string            `json:"bucketId"`
+	AccessKeyID string            `json:"accessKeyId"`
+	Permissions garagePermissions `json:"permissions"`
+}
+
+// adminRequest sends a request to the Garage admin API and decodes the JSON response into out
+func (g *Garage) adminRequest(ctx context.Context, method, path string, query url.Values, payload, out any) error {
+	if g.adminEndpointURL == "" {
+		return fmt.Errorf("garage admin endpoint URL is not configured")
+	}
+
+	endpoint := strings.TrimSuffix(g.adminEndpointURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.adminToken)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errGarageNotFound
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("%s %s failed: status %d, body: %s", method, path, resp.StatusCode, string(respBody))
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// getKey returns information about an access key, or errGarageNotFound
+func (g *Garage) getKey(ctx context.Context, accessKey string, showSecret bool) (*garageKeyInfo, error) {
+	query := url.Values{"id": {accessKey}}
+	if showSecret {
+		query.Set("showSecretKey", "true")
+	}
+
+	var info garageKeyInfo
+	if err := g.adminRequest(ctx, http.MethodGet, "/v1/key", query, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// importKey imports an access key with a caller-provided secret
+func (g *Garage) importKey(ctx context.Context, accessKey, secretKey string) error {
+	return g.adminRequest(ctx, http.MethodPost, "/v1/key/import", nil, garageImportKeyRequest{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		Name:            accessKey,
+	}, nil)
+}
+
+// getBucket looks up a bucket by its global alias, or returns errGarageNotFound
+func (g *Garage) getBucket(ctx context.Context, bucketName string) (*garageBucketInfo, error) {
+	var info garageBucketInfo
+	if err := g.adminRequest(ctx, http.MethodGet, "/v1/bucket", url.Values{"globalAlias": {bucketName}}, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// setBucketPermissions allows or denies bucket permissions for an access key
+func (g *Garage) setBucketPermissions(ctx context.Context, bucketID, accessKey string, permissions garagePermissions, allow bool) error {
+	path := "/v1/bucket/deny"
+	if allow {
+		path = "/v1/bucket/allow"
+	}
+	return g.adminRequest(ctx, http.MethodPost, path, nil, garageBucketPermissionRequest{
+		BucketID:    bucketID,
+		AccessKeyID: accessKey,
+		Permissions: permissions,
+	}, nil)
+}
+
+// updateBucketQuotas replaces the quotas of a bucket
+func (g *Garage) updateBucketQuotas(ctx context.Context, bucketID string, quotas garageBucketQuotas) error {
+	return g.adminRequest(ctx, http.MethodPut, "/v1/bucket", url.Values{"id": {bucketID}}, garageUpdateBucketRequest{
+		Quotas: &quotas,
+	}, nil)
+}
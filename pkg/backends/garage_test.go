@@ -0,0 +1,310 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeGarageAdmin is a minimal stand-in for the Garage v1 admin API
+type fakeGarageAdmin struct {
+	mu      sync.Mutex
+	keys    map[string]string                       // accessKeyId -> secret
+	buckets map[string]string                       // globalAlias -> bucket ID
+	perms   map[string]map[string]garagePermissions // bucket ID -> accessKeyId -> permissions
+	quotas  map[string]garageBucketQuotas           // bucket ID -> quotas
+	// deletedKeys are tombstones, Garage refuses to import their IDs again
+	deletedKeys map[string]bool
+}
+
+func newFakeGarageAdmin(t *testing.T, token string) (*fakeGarageAdmin, *httptest.Server) {
+	f := &fakeGarageAdmin{
+		keys:        make(map[string]string),
+		buckets:     make(map[string]string),
+		perms:       make(map[string]map[string]garagePermissions),
+		quotas:      make(map[string]garageBucketQuotas),
+		deletedKeys: make(map[string]bool),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/status":
+			_, _ = w.Write([]byte(`{"node":"test"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/key":
+			id := query.Get("id")
+			secret, ok := f.keys[id]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			info := garageKeyInfo{Name: id, AccessKeyID: id}
+			if query.Get("showSecretKey") == "true" {
+				info.SecretAccessKey = &secret
+			}
+			for alias, bucketID := range f.buckets {
+				if p, ok := f.perms[bucketID][id]; ok {
+					info.Buckets = append(info.Buckets, garageKeyBucket{ID: bucketID, GlobalAliases: []string{alias}, Permissions: p})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(info)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/key/import":
+			var req garageImportKeyRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if _, ok := f.keys[req.AccessKeyID]; ok || f.deletedKeys[req.AccessKeyID] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.keys[req.AccessKeyID] = req.SecretAccessKey
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/key":
+			id := query.Get("id")
+			if _, ok := f.keys[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(f.keys, id)
+			f.deletedKeys[id] = true
+			for _, perms := range f.perms {
+				delete(perms, id)
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/bucket":
+			bucketID, ok := f.buckets[query.Get("globalAlias")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			info := garageBucketInfo{ID: bucketID, GlobalAliases: []string{query.Get("globalAlias")}, Quotas: f.quotas[bucketID]}
+			for id, p := range f.perms[bucketID] {
+				info.Keys = append(info.Keys, garageBucketKey{AccessKeyID: id, Permissions: p})
+			}
+			_ = json.NewEncoder(w).Encode(info)
+		case r.Method == http.MethodPost && (r.URL.Path == "/v1/bucket/allow" || r.URL.Path == "/v1/bucket/deny"):
+			var req garageBucketPermissionRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if f.perms[req.BucketID] == nil {
+				f.perms[req.BucketID] = make(map[string]garagePermissions)
+			}
+			p := f.perms[req.BucketID][req.AccessKeyID]
+			allow := r.URL.Path == "/v1/bucket/allow"
+			if req.Permissions.Read {
+				p.Read = allow
+			}
+			if req.Permissions.Write {
+				p.Write = allow
+			}
+			if req.Permissions.Owner {
+				p.Owner = allow
+			}
+			f.perms[req.BucketID][req.AccessKeyID] = p
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/bucket":
+			var req garageUpdateBucketRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Quotas != nil {
+				f.quotas[query.Get("id")] = *req.Quotas
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func newTestGarage(adminURL string) *Garage {
+	return NewGarage(Config{
+		EndpointURL:      "http://localhost:3900",
+		AccessKey:        "root",
+		SecretKey:        "root-secret",
+		AdminEndpointURL: adminURL,
+		AdminToken:       "admin-token",
+	})
+}
+
+func TestGarage_UserLifecycle(t *testing.T) {
+	ctx := context.Background()
+	_, server := newFakeGarageAdmin(t, "admin-token")
+	backend := newTestGarage(server.URL)
+
+	exists, err := backend.UserExists(ctx, "GK0001")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if exists {
+		t.Fatal("expected key to not exist")
+	}
+
+	if err := backend.CreateUser(ctx, "GK0001", "secret-1", nil, nil, nil); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	exists, err = backend.UserExists(ctx, "GK0001")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("expected key to exist")
+	}
+
+	// Same secret: nothing to do
+	sameSecret := "secret-1"
+	if err := backend.UpdateUser(ctx, "GK0001", &sameSecret, nil, nil); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+
+	if err := backend.DeleteUser(ctx, "GK0001"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	exists, err = backend.UserExists(ctx, "GK0001")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if exists {
+		t.Error("expected key to not exist after deletion")
+	}
+}
+
+func TestGarage_UpdateUserRejectsSecretChange(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeGarageAdmin(t, "admin-token")
+	backend := newTestGarage(server.URL)
+
+	if err := backend.CreateUser(ctx, "GK0001", "secret-1", nil, nil, nil); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	fake.buckets["data"] = "bucket-id-1"
+	if err := backend.ChangeBucketOwner(ctx, "data", "GK0001"); err != nil {
+		t.Fatalf("ChangeBucketOwner failed: %v", err)
+	}
+
+	newSecret := "secret-2"
+	if err := backend.UpdateUser(ctx, "GK0001", &newSecret, nil, nil); !errors.Is(err, ErrSecretKeyChangeUnsupported) {
+		t.Fatalf("expected ErrSecretKeyChangeUnsupported, got %v", err)
+	}
+	if fake.keys["GK0001"] != "secret-1" {
+		t.Errorf("expected key to keep its secret, got %q", fake.keys["GK0001"])
+	}
+	if p := fake.perms["bucket-id-1"]["GK0001"]; !p.Owner || !p.Read || !p.Write {
+		t.Errorf("expected bucket permissions to be kept, got %+v", p)
+	}
+
+	// Garage never imports a deleted key ID again
+	if err := backend.DeleteUser(ctx, "GK0001"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if err := backend.CreateUser(ctx, "GK0001", "secret-2", nil, nil, nil); err == nil {
+		t.Error("expected import of a deleted key ID to fail")
+	}
+}
+
+func TestGarage_BucketOwnership(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeGarageAdmin(t, "admin-token")
+	backend := newTestGarage(server.URL)
+
+	fake.keys["GK0001"] = "secret-1"
+	fake.keys["GK0002"] = "secret-2"
+	fake.buckets["shared"] = "bucket-id-1"
+
+	owner, err := backend.GetBucketOwner(ctx, "shared")
+	if err != nil {
+		t.Fatalf("GetBucketOwner failed: %v", err)
+	}
+	if owner != "" {
+		t.Errorf("expected no owner, got %q", owner)
+	}
+
+	if err := backend.ChangeBucketOwner(ctx, "shared", "GK0001"); err != nil {
+		t.Fatalf("ChangeBucketOwner failed: %v", err)
+	}
+	owner, err = backend.GetBucketOwner(ctx, "shared")
+	if err != nil {
+		t.Fatalf("GetBucketOwner failed: %v", err)
+	}
+	if owner != "GK0001" {
+		t.Errorf("expected owner GK0001, got %q", owner)
+	}
+
+	// Transferring ownership revokes the previous owner's permissions
+	if err := backend.ChangeBucketOwner(ctx, "shared", "GK0002"); err != nil {
+		t.Fatalf("ChangeBucketOwner failed: %v", err)
+	}
+	if p := fake.perms["bucket-id-1"]["GK0001"]; p.Owner || p.Read || p.Write {
+		t.Errorf("expected previous owner permissions to be revoked, got %+v", p)
+	}
+	owner, err = backend.GetBucketOwner(ctx, "shared")
+	if err != nil {
+		t.Fatalf("GetBucketOwner failed: %v", err)
+	}
+	if owner != "GK0002" {
+		t.Errorf("expected owner GK0002, got %q", owner)
+	}
+
+	if _, err := backend.GetBucketOwner(ctx, "missing"); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
+
+func TestGarage_BucketQuota(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeGarageAdmin(t, "admin-token")
+	backend := newTestGarage(server.URL)
+
+	fake.buckets["data"] = "bucket-id-1"
+
+	quota, err := backend.GetBucketQuota(ctx, "data")
+	if err != nil {
+		t.Fatalf("GetBucketQuota failed: %v", err)
+	}
+	if !quota.IsZero() {
+		t.Errorf("expected no quota, got %+v", quota)
+	}
+
+	want := BucketQuota{MaxSize: 1 << 30, MaxObjects: 1000}
+	if err := backend.SetBucketQuota(ctx, "data", want); err != nil {
+		t.Fatalf("SetBucketQuota failed: %v", err)
+	}
+	quota, err = backend.GetBucketQuota(ctx, "data")
+	if err != nil {
+		t.Fatalf("GetBucketQuota failed: %v", err)
+	}
+	if quota != want {
+		t.Errorf("expected quota %+v, got %+v", want, quota)
+	}
+
+	// Zero values are sent as null, which Garage treats as unlimited
+	if err := backend.SetBucketQuota(ctx, "data", BucketQuota{MaxSize: 1 << 20}); err != nil {
+		t.Fatalf("SetBucketQuota failed: %v", err)
+	}
+	if q := fake.quotas["bucket-id-1"]; q.MaxSize == nil || *q.MaxSize != 1<<20 || q.MaxObjects != nil {
+		t.Errorf("unexpected quotas %+v", q)
+	}
+
+	if err := backend.SetBucketQuota(ctx, "missing", want); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
+
+func TestGarage_AdminTokenRejected(t *testing.T) {
+	_, server := newFakeGarageAdmin(t, "admin-token")
+	backend := NewGarage(Config{
+		EndpointURL:      "http://localhost:3900",
+		AdminEndpointURL: server.URL,
+		AdminToken:       "wrong-token",
+	})
+
+	if _, err := backend.UserExists(context.Background(), "GK0001"); err == nil {
+		t.Error("expected error for rejected admin token, got nil")
+	}
+}
@@ -2,11 +2,16 @@ package backends
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/aws/aws-sdk-go/service/s3"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -17,6 +22,8 @@ type MinIO struct {
 	accessKey   string
 	secretKey   string
 	s3Client    *s3.S3
+	httpClient  *http.Client
+	signer      *v4.Signer
 }
 
 // NewMinIO creates a new MinIO backend
@@ -28,11 +35,15 @@ func NewMinIO(config Config) *MinIO {
 		S3ForcePathStyle: aws.Bool(true),
 	}))
 
+	creds := credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+
 	return &MinIO{
 		endpointURL: config.EndpointURL,
 		accessKey:   config.AccessKey,
 		secretKey:   config.SecretKey,
 		s3Client:    s3.New(sess),
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		signer:      v4.NewSigner(creds),
 	}
 }
 
@@ -54,27 +65,23 @@ func (m *MinIO) TestConnection(ctx context.Context) error {
 }
 
 func (m *MinIO) CreateBucket(ctx context.Context, bucketName string, owner *string) error {
-	_, err := m.s3Client.CreateBucketWithContext(ctx, &s3.CreateBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	return err
+	return createS3Bucket(ctx, m.s3Client, bucketName, false)
+}
+
+func (m *MinIO) CreateBucketWithObjectLock(ctx context.Context, bucketName string, owner *string) error {
+	return createS3Bucket(ctx, m.s3Client, bucketName, true)
 }
 
 func (m *MinIO) DeleteBucket(ctx context.Context, bucketName string) error {
-	_, err := m.s3Client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	return err
+	return deleteS3Bucket(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) EmptyBucket(ctx context.Context, bucketName string) error {
+	return emptyS3Bucket(ctx, m.s3Client, bucketName)
 }
 
 func (m *MinIO) BucketExists(ctx context.Context, bucketName string) (bool, error) {
-	_, err := m.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
-		return false, nil
-	}
-	return true, nil
+	return s3BucketExists(ctx, m.s3Client, bucketName)
 }
 
 func (m *MinIO) GetBucketOwner(ctx context.Context, bucketName string) (string, error) {
@@ -85,18 +92,176 @@ func (m *MinIO) ChangeBucketOwner(ctx context.Context, bucketName, newOwner stri
 	return fmt.Errorf("not implemented for MinIO")
 }
 
+func (m *MinIO) GetBucketVersioning(ctx context.Context, bucketName string) (bool, error) {
+	return getS3BucketVersioning(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	return putS3BucketVersioning(ctx, m.s3Client, bucketName, enabled)
+}
+
+func (m *MinIO) GetObjectLockConfiguration(ctx context.Context, bucketName string) (*ObjectLockConfiguration, error) {
+	return getS3ObjectLockConfiguration(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutObjectLockConfiguration(ctx context.Context, bucketName string, config ObjectLockConfiguration) error {
+	return putS3ObjectLockConfiguration(ctx, m.s3Client, bucketName, config)
+}
+
+func (m *MinIO) GetBucketPolicy(ctx context.Context, bucketName string) (string, error) {
+	return getS3BucketPolicy(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketPolicy(ctx context.Context, bucketName, policy string) error {
+	return putS3BucketPolicy(ctx, m.s3Client, bucketName, policy)
+}
+
+func (m *MinIO) DeleteBucketPolicy(ctx context.Context, bucketName string) error {
+	return deleteS3BucketPolicy(ctx, m.s3Client, bucketName)
+}
+
+// CreateUser creates a MinIO user. The optional role is attached as a MinIO policy name.
 func (m *MinIO) CreateUser(ctx context.Context, accessKey, secretKey string, role *string, userID, groupID *int) error {
-	return fmt.Errorf("not implemented for MinIO")
+	if err := m.addUser(ctx, accessKey, secretKey); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if role != nil {
+		query := url.Values{
+			"policyName":  {*role},
+			"userOrGroup": {accessKey},
+			"isGroup":     {"false"},
+		}
+		if _, err := m.adminRequest(ctx, http.MethodPut, "set-user-or-group-policy", query, nil); err != nil {
+			return fmt.Errorf("failed to attach policy %s to user: %w", *role, err)
+		}
+	}
+
+	ctrl.Log.WithName("minio").Info("Created user", "user", accessKey)
+	return nil
 }
 
 func (m *MinIO) DeleteUser(ctx context.Context, accessKey string) error {
-	return fmt.Errorf("not implemented for MinIO")
+	if _, err := m.adminRequest(ctx, http.MethodDelete, "remove-user", url.Values{"accessKey": {accessKey}}, nil); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	ctrl.Log.WithName("minio").Info("Deleted user", "user", accessKey)
+	return nil
 }
 
+// UpdateUser replaces the secret key of a user and makes sure it is enabled.
+// MinIO has no numeric user or group IDs, so userID and groupID are ignored.
 func (m *MinIO) UpdateUser(ctx context.Context, accessKey string, secretKey *string, userID, groupID *int) error {
-	return fmt.Errorf("not implemented for MinIO")
+	if secretKey != nil {
+		if err := m.addUser(ctx, accessKey, *secretKey); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+	} else if err := m.SetUserStatus(ctx, accessKey, true); err != nil {
+		return err
+	}
+
+	ctrl.Log.WithName("minio").Info("Updated user", "user", accessKey)
+	return nil
 }
 
 func (m *MinIO) UserExists(ctx context.Context, accessKey string) (bool, error) {
-	return false, fmt.Errorf("not implemented for MinIO")
+	_, err := m.getUserInfo(ctx, accessKey)
+	if errors.Is(err, errMinIONoSuchUser) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get user info: %w", err)
+	}
+	return true, nil
+}
+
+// SetUserStatus enables or disables a MinIO user
+func (m *MinIO) SetUserStatus(ctx context.Context, accessKey string, enabled bool) error {
+	status := "disabled"
+	if enabled {
+		status = "enabled"
+	}
+
+	query := url.Values{"accessKey": {accessKey}, "status": {status}}
+	if _, err := m.adminRequest(ctx, http.MethodPut, "set-user-status", query, nil); err != nil {
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+	return nil
+}
+
+func (m *MinIO) VerifyCredentials(ctx context.Context, accessKey, secretKey string) (bool, error) {
+	return verifyS3Credentials(ctx, m.endpointURL, accessKey, secretKey)
+}
+
+func (m *MinIO) GetBucketLifecycle(ctx context.Context, bucketName string) ([]LifecycleRule, error) {
+	return getS3BucketLifecycle(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) error {
+	return putS3BucketLifecycle(ctx, m.s3Client, bucketName, rules)
+}
+
+func (m *MinIO) DeleteBucketLifecycle(ctx context.Context, bucketName string) error {
+	return deleteS3BucketLifecycle(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) GetBucketQuota(ctx context.Context, bucketName string) (BucketQuota, error) {
+	quota, err := m.getBucketQuota(ctx, bucketName)
+	if errors.Is(err, errMinIONoSuchQuota) {
+		return BucketQuota{}, nil
+	}
+	if err != nil {
+		return BucketQuota{}, fmt.Errorf("failed to get bucket quota: %w", err)
+	}
+
+	size := quota.Size
+	if size == 0 {
+		size = quota.Quota
+	}
+	return BucketQuota{MaxSize: size}, nil
+}
+
+// SetBucketQuota sets a hard size quota on a bucket. MinIO cannot limit the number of
+// objects in a bucket, so MaxObjects is ignored.
+func (m *MinIO) SetBucketQuota(ctx context.Context, bucketName string, quota BucketQuota) error {
+	if err := m.setBucketQuota(ctx, bucketName, quota.MaxSize); err != nil {
+		return fmt.Errorf("failed to set bucket quota: %w", err)
+	}
+
+	ctrl.Log.WithName("minio").Info("Set bucket quota", "bucket", bucketName, "maxSize", quota.MaxSize)
+	return nil
+}
+
+// SupportsObjectQuota returns false, MinIO quotas only limit the size of a bucket
+func (m *MinIO) SupportsObjectQuota() bool {
+	return false
+}
+
+func (m *MinIO) GetBucketCors(ctx context.Context, bucketName string) ([]CORSRule, error) {
+	return getS3BucketCors(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketCors(ctx context.Context, bucketName string, rules []CORSRule) error {
+	return putS3BucketCors(ctx, m.s3Client, bucketName, rules)
+}
+
+func (m *MinIO) DeleteBucketCors(ctx context.Context, bucketName string) error {
+	return deleteS3BucketCors(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error) {
+	return getS3BucketEncryption(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketEncryption(ctx context.Context, bucketName string, encryption BucketEncryption) error {
+	return putS3BucketEncryption(ctx, m.s3Client, bucketName, encryption)
+}
+
+func (m *MinIO) GetBucketTagging(ctx context.Context, bucketName string) (map[string]string, error) {
+	return getS3BucketTagging(ctx, m.s3Client, bucketName)
+}
+
+func (m *MinIO) PutBucketTagging(ctx context.Context, bucketName string, tags map[string]string) error {
+	return putS3BucketTagging(ctx, m.s3Client, bucketName, tags)
 }
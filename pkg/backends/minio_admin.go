@@ -0,0 +1,232 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// minioAdminPrefix is the path prefix of the MinIO admin API
+	minioAdminPrefix = "/minio/admin/v3"
+
+	// Parameters of the madmin payload encryption (PBKDF2 + AES-256-GCM variant)
+	minioPBKDF2Cost      = 8192
+	minioPBKDF2AESGCM    = 0x02
+	minioSaltSize        = 32
+	minioStreamBufSize   = 16 * 1024
+	minioStreamFinalFlag = 0x80
+)
+
+var (
+	// errMinIONoSuchUser is returned by the admin API when the requested user does not exist
+	errMinIONoSuchUser = errors.New("minio: no such user")
+
+	// errMinIONoSuchQuota is returned by the admin API when a bucket has no quota
+	errMinIONoSuchQuota = errors.New("minio: no such quota configuration")
+)
+
+// minioAdminError is the JSON error document returned by the MinIO admin API
+type minioAdminError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+// minioAddUserRequest is the (encrypted) payload of the add-user call
+type minioAddUserRequest struct {
+	SecretKey string `json:"secretKey,omitempty"`
+	Status    string `json:"status"`
+}
+
+// minioUserInfo is the response of the user-info call
+type minioUserInfo struct {
+	PolicyName string `json:"policyName,omitempty"`
+	Status     string `json:"status"`
+}
+
+// minioBucketQuota is the payload of the set-bucket-quota call and the response of
+// the get-bucket-quota call
+type minioBucketQuota struct {
+	// Quota is the deprecated predecessor of Size, still returned by older servers
+	Quota     int64  `json:"quota"`
+	Size      int64  `json:"size"`
+	QuotaType string `json:"quotatype,omitempty"`
+}
+
+// adminRequest sends a signed request to the MinIO admin API and returns the response body
+func (m *MinIO) adminRequest(ctx context.Context, method, action string, query url.Values, body []byte) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s%s/%s", strings.TrimSuffix(m.endpointURL, "/"), minioAdminPrefix, action)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := signV4Request(m.signer, req, body); err != nil {
+		return nil, err
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		var adminErr minioAdminError
+		if json.Unmarshal(respBody, &adminErr) == nil {
+			switch adminErr.Code {
+			case "XMinioAdminNoSuchUser":
+				return nil, errMinIONoSuchUser
+			case "XMinioAdminNoSuchQuotaConfiguration":
+				return nil, errMinIONoSuchQuota
+			}
+		}
+		return nil, fmt.Errorf("%s failed: status %d, body: %s", action, resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
+
+// addUser creates a user or replaces the secret key of an existing one
+func (m *MinIO) addUser(ctx context.Context, accessKey, secretKey string) error {
+	payload, err := json.Marshal(minioAddUserRequest{
+		SecretKey: secretKey,
+		Status:    "enabled",
+	})
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptMinIOAdminData(m.secretKey, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt add-user payload: %w", err)
+	}
+
+	_, err = m.adminRequest(ctx, http.MethodPut, "add-user", url.Values{"accessKey": {accessKey}}, encrypted)
+	return err
+}
+
+// getUserInfo returns information about a user, or errMinIONoSuchUser
+func (m *MinIO) getUserInfo(ctx context.Context, accessKey string) (*minioUserInfo, error) {
+	body, err := m.adminRequest(ctx, http.MethodGet, "user-info", url.Values{"accessKey": {accessKey}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info minioUserInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %w", err)
+	}
+	return &info, nil
+}
+
+// getBucketQuota returns the quota of a bucket, or errMinIONoSuchQuota
+func (m *MinIO) getBucketQuota(ctx context.Context, bucketName string) (*minioBucketQuota, error) {
+	body, err := m.adminRequest(ctx, http.MethodGet, "get-bucket-quota", url.Values{"bucket": {bucketName}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var quota minioBucketQuota
+	if err := json.Unmarshal(body, &quota); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// setBucketQuota sets a hard quota on a bucket; a size of zero removes the quota
+func (m *MinIO) setBucketQuota(ctx context.Context, bucketName string, size int64) error {
+	quota := minioBucketQuota{Quota: size, Size: size}
+	if size > 0 {
+		quota.QuotaType = "hard"
+	}
+	payload, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.adminRequest(ctx, http.MethodPut, "set-bucket-quota", url.Values{"bucket": {bucketName}}, payload)
+	return err
+}
+
+// encryptMinIOAdminData encrypts an admin API payload in the format expected by madmin.DecryptData:
+// salt (32 bytes) | algorithm ID (1 byte) | nonce (8 bytes) | sio-go AES-256-GCM stream.
+// The PBKDF2 variant is used because it only needs the standard library.
+func encryptMinIOAdminData(password string, data []byte) ([]byte, error) {
+	salt := make([]byte, minioSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, minioPBKDF2Cost, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// sio-go uses the last 4 bytes of the AEAD nonce as a little-endian sequence number
+	nonceSize := aead.NonceSize() - 4
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce[:nonceSize]); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, minioSaltSize+1+nonceSize+len(data)+(len(data)/minioStreamBufSize+1)*aead.Overhead())
+	out = append(out, salt...)
+	out = append(out, minioPBKDF2AESGCM)
+	out = append(out, nonce[:nonceSize]...)
+
+	// The associated data of every fragment is a flag byte followed by the
+	// authentication tag of the (empty) stream associated data sealed with sequence number 0
+	associatedData := aead.Seal([]byte{0}, nonce, nil, nil)
+
+	for seqNum := uint32(1); ; seqNum++ {
+		n := min(len(data), minioStreamBufSize)
+		final := n == len(data)
+		if final {
+			associatedData[0] = minioStreamFinalFlag
+		}
+
+		binary.LittleEndian.PutUint32(nonce[nonceSize:], seqNum)
+		out = aead.Seal(out, nonce, data[:n], associatedData)
+		data = data[n:]
+
+		if final {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package backends
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// decryptMinIOAdminData mirrors madmin.DecryptData for the PBKDF2 + AES-GCM variant
+func decryptMinIOAdminData(password string, data []byte) ([]byte, error) {
+	if len(data) < minioSaltSize+1+8 {
+		return nil, fmt.Errorf("payload too short")
+	}
+	salt, id, data := data[:minioSaltSize], data[minioSaltSize], data[minioSaltSize+1:]
+	if id != minioPBKDF2AESGCM {
+		return nil, fmt.Errorf("unexpected algorithm id %d", id)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, minioPBKDF2Cost, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aead.NonceSize() - 4
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, data[:nonceSize])
+	data = data[nonceSize:]
+
+	associatedData := aead.Seal([]byte{0}, nonce, nil, nil)
+
+	var plaintext []byte
+	fragmentSize := minioStreamBufSize + aead.Overhead()
+	for seqNum := uint32(1); ; seqNum++ {
+		n := min(len(data), fragmentSize)
+		final := n == len(data)
+		if final {
+			associatedData[0] = minioStreamFinalFlag
+		}
+		binary.LittleEndian.PutUint32(nonce[nonceSize:], seqNum)
+		plaintext, err = aead.Open(plaintext, nonce, data[:n], associatedData)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+		if final {
+			return plaintext, nil
+		}
+	}
+}
+
+func TestEncryptMinIOAdminData_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 64},
+		{"exactly one fragment", minioStreamBufSize},
+		{"multiple fragments", 2*minioStreamBufSize + 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := []byte(strings.Repeat("x", tt.size))
+
+			encrypted, err := encryptMinIOAdminData("root-secret", plaintext)
+			if err != nil {
+				t.Fatalf("encrypt failed: %v", err)
+			}
+
+			decrypted, err := decryptMinIOAdminData("root-secret", encrypted)
+			if err != nil {
+				t.Fatalf("decrypt failed: %v", err)
+			}
+			if string(decrypted) != string(plaintext) {
+				t.Errorf("round trip mismatch: got %d bytes, want %d", len(decrypted), len(plaintext))
+			}
+
+			if _, err := decryptMinIOAdminData("wrong-secret", encrypted); err == nil {
+				t.Error("expected decryption with wrong password to fail")
+			}
+		})
+	}
+}
+
+// fakeMinIOAdmin is a minimal stand-in for the MinIO admin API user endpoints
+type fakeMinIOAdmin struct {
+	mu       sync.Mutex
+	users    map[string]minioUserInfo
+	secrets  map[string]string
+	policies map[string]string
+	quotas   map[string]minioBucketQuota
+}
+
+func newFakeMinIOAdmin(t *testing.T, rootSecret string) (*fakeMinIOAdmin, *httptest.Server) {
+	f := &fakeMinIOAdmin{
+		users:    make(map[string]minioUserInfo),
+		secrets:  make(map[string]string),
+		policies: make(map[string]string),
+		quotas:   make(map[string]minioBucketQuota),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		accessKey := r.URL.Query().Get("accessKey")
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == minioAdminPrefix+"/add-user":
+			body, _ := io.ReadAll(r.Body)
+			plaintext, err := decryptMinIOAdminData(rootSecret, body)
+			if err != nil {
+				t.Errorf("failed to decrypt add-user payload: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var req minioAddUserRequest
+			if err := json.Unmarshal(plaintext, &req); err != nil {
+				t.Errorf("failed to decode add-user payload: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			info := f.users[accessKey]
+			info.Status = req.Status
+			f.users[accessKey] = info
+			f.secrets[accessKey] = req.SecretKey
+		case r.Method == http.MethodGet && r.URL.Path == minioAdminPrefix+"/user-info":
+			info, ok := f.users[accessKey]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(minioAdminError{Code: "XMinioAdminNoSuchUser", Message: "not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(info)
+		case r.Method == http.MethodDelete && r.URL.Path == minioAdminPrefix+"/remove-user":
+			if _, ok := f.users[accessKey]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(minioAdminError{Code: "XMinioAdminNoSuchUser", Message: "not found"})
+				return
+			}
+			delete(f.users, accessKey)
+			delete(f.secrets, accessKey)
+		case r.Method == http.MethodPut && r.URL.Path == minioAdminPrefix+"/set-user-status":
+			info, ok := f.users[accessKey]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(minioAdminError{Code: "XMinioAdminNoSuchUser", Message: "not found"})
+				return
+			}
+			info.Status = r.URL.Query().Get("status")
+			f.users[accessKey] = info
+		case r.Method == http.MethodPut && r.URL.Path == minioAdminPrefix+"/set-user-or-group-policy":
+			user := r.URL.Query().Get("userOrGroup")
+			info := f.users[user]
+			info.PolicyName = r.URL.Query().Get("policyName")
+			f.users[user] = info
+			f.policies[user] = info.PolicyName
+		case r.Method == http.MethodGet && r.URL.Path == minioAdminPrefix+"/get-bucket-quota":
+			quota, ok := f.quotas[r.URL.Query().Get("bucket")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(minioAdminError{Code: "XMinioAdminNoSuchQuotaConfiguration", Message: "not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(quota)
+		case r.Method == http.MethodPut && r.URL.Path == minioAdminPrefix+"/set-bucket-quota":
+			var quota minioBucketQuota
+			if err := json.NewDecoder(r.Body).Decode(&quota); err != nil {
+				t.Errorf("failed to decode set-bucket-quota payload: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			bucket := r.URL.Query().Get("bucket")
+			if quota.Size == 0 {
+				delete(f.quotas, bucket)
+			} else {
+				f.quotas[bucket] = quota
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func TestMinIO_UserLifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeMinIOAdmin(t, "root-secret")
+
+	backend := NewMinIO(Config{
+		EndpointURL: server.URL,
+		AccessKey:   "root",
+		SecretKey:   "root-secret",
+	})
+
+	exists, err := backend.UserExists(ctx, "app-user")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if exists {
+		t.Fatal("expected user to not exist")
+	}
+
+	role := "readwrite"
+	if err := backend.CreateUser(ctx, "app-user", "app-secret", &role, nil, nil); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if fake.secrets["app-user"] != "app-secret" {
+		t.Errorf("expected secret 'app-secret', got %q", fake.secrets["app-user"])
+	}
+	if fake.policies["app-user"] != "readwrite" {
+		t.Errorf("expected policy 'readwrite', got %q", fake.policies["app-user"])
+	}
+
+	exists, err = backend.UserExists(ctx, "app-user")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("expected user to exist")
+	}
+
+	newSecret := "rotated-secret"
+	if err := backend.UpdateUser(ctx, "app-user", &newSecret, nil, nil); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if fake.secrets["app-user"] != "rotated-secret" {
+		t.Errorf("expected secret 'rotated-secret', got %q", fake.secrets["app-user"])
+	}
+
+	if err := backend.SetUserStatus(ctx, "app-user", false); err != nil {
+		t.Fatalf("SetUserStatus failed: %v", err)
+	}
+	if fake.users["app-user"].Status != "disabled" {
+		t.Errorf("expected status 'disabled', got %q", fake.users["app-user"].Status)
+	}
+
+	// Updating without a secret re-enables the user
+	if err := backend.UpdateUser(ctx, "app-user", nil, nil, nil); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if fake.users["app-user"].Status != "enabled" {
+		t.Errorf("expected status 'enabled', got %q", fake.users["app-user"].Status)
+	}
+
+	if err := backend.DeleteUser(ctx, "app-user"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	exists, err = backend.UserExists(ctx, "app-user")
+	if err != nil {
+		t.Fatalf("UserExists failed: %v", err)
+	}
+	if exists {
+		t.Error("expected user to not exist after deletion")
+	}
+
+	if err := backend.DeleteUser(ctx, "app-user"); err == nil {
+		t.Error("expected error when deleting missing user")
+	}
+}
+
+func TestMinIO_UserExistsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(minioAdminError{Code: "AccessDenied", Message: "denied"})
+	}))
+	defer server.Close()
+
+	backend := NewMinIO(Config{EndpointURL: server.URL, AccessKey: "root", SecretKey: "root-secret"})
+
+	if _, err := backend.UserExists(context.Background(), "app-user"); err == nil {
+		t.Error("expected error for access denied, got nil")
+	}
+}
+
+func TestMinIO_BucketQuota(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeMinIOAdmin(t, "root-secret")
+
+	backend := NewMinIO(Config{EndpointURL: server.URL, AccessKey: "root", SecretKey: "root-secret"})
+
+	quota, err := backend.GetBucketQuota(ctx, "data")
+	if err != nil {
+		t.Fatalf("GetBucketQuota failed: %v", err)
+	}
+	if !quota.IsZero() {
+		t.Errorf("expected no quota, got %+v", quota)
+	}
+
+	if err := backend.SetBucketQuota(ctx, "data", BucketQuota{MaxSize: 1 << 30}); err != nil {
+		t.Fatalf("SetBucketQuota failed: %v", err)
+	}
+	if q := fake.quotas["data"]; q.Size != 1<<30 || q.QuotaType != "hard" {
+		t.Errorf("unexpected quota payload %+v", q)
+	}
+	quota, err = backend.GetBucketQuota(ctx, "data")
+	if err != nil {
+		t.Fatalf("GetBucketQuota failed: %v", err)
+	}
+	if quota != (BucketQuota{MaxSize: 1 << 30}) {
+		t.Errorf("expected 1GiB quota, got %+v", quota)
+	}
+
+	// Older servers only report the deprecated quota field
+	fake.quotas["legacy"] = minioBucketQuota{Quota: 1024, QuotaType: "hard"}
+	quota, err = backend.GetBucketQuota(ctx, "legacy")
+	if err != nil {
+		t.Fatalf("GetBucketQuota failed: %v", err)
+	}
+	if quota.MaxSize != 1024 {
+		t.Errorf("expected quota of 1024 bytes, got %+v", quota)
+	}
+
+	if backend.SupportsObjectQuota() {
+		t.Error("expected minio not to support object count quotas")
+	}
+	if err := backend.SetBucketQuota(ctx, "data", BucketQuota{MaxSize: 2048, MaxObjects: 10}); err != nil {
+		t.Fatalf("SetBucketQuota with object count failed: %v", err)
+	}
+	if q := fake.quotas["data"]; q.Size != 2048 {
+		t.Errorf("expected size quota to be applied, got %+v", q)
+	}
+
+	if err := backend.SetBucketQuota(ctx, "data", BucketQuota{}); err != nil {
+		t.Fatalf("SetBucketQuota failed: %v", err)
+	}
+	if _, ok := fake.quotas["data"]; ok {
+		t.Error("expected quota to be removed")
+	}
+}
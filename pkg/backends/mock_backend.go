@@ -3,6 +3,7 @@ package backends
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
 )
 
@@ -14,10 +15,26 @@ type MockBackend struct {
 	Buckets     map[string]string // bucketName -> owner
 	Users       map[string]*MockUser
 
+	// NonEmptyBuckets marks buckets that still contain objects
+	NonEmptyBuckets map[string]bool
+	Policies        map[string]string // bucketName -> policy document
+	Versioning      map[string]bool   // bucketName -> versioning enabled
+	// ObjectLocks holds the object lock configuration of buckets created with object lock
+	ObjectLocks map[string]*ObjectLockConfiguration
+	Lifecycles  map[string][]LifecycleRule   // bucketName -> lifecycle rules
+	Quotas      map[string]BucketQuota       // bucketName -> quota
+	CORS        map[string][]CORSRule        // bucketName -> CORS rules
+	Encryption  map[string]BucketEncryption  // bucketName -> default encryption
+	Tags        map[string]map[string]string // bucketName -> tags
+
+	// NoObjectQuota makes the mock behave like backends whose quotas cannot limit the object count
+	NoObjectQuota bool
+
 	// Error injection
 	TestConnectionError    error
 	CreateBucketError      error
 	DeleteBucketError      error
+	EmptyBucketError       error
 	BucketExistsError      error
 	GetBucketOwnerError    error
 	ChangeBucketOwnerError error
@@ -25,18 +42,44 @@ type MockBackend struct {
 	DeleteUserError        error
 	UpdateUserError        error
 	UserExistsError        error
+	GetBucketPolicyError   error
+	PutBucketPolicyError   error
+	VerifyCredentialsError error
+
+	PutBucketVersioningError        error
+	PutObjectLockConfigurationError error
+	PutBucketLifecycleError         error
+	SetBucketQuotaError             error
+	PutBucketCorsError              error
+	PutBucketEncryptionError        error
+	PutBucketTaggingError           error
 
 	// Call tracking
-	TestConnectionCalls    int
-	CreateBucketCalls      int
-	DeleteBucketCalls      int
-	BucketExistsCalls      int
-	GetBucketOwnerCalls    int
-	ChangeBucketOwnerCalls int
-	CreateUserCalls        int
-	DeleteUserCalls        int
-	UpdateUserCalls        int
-	UserExistsCalls        int
+	TestConnectionCalls     int
+	CreateBucketCalls       int
+	DeleteBucketCalls       int
+	EmptyBucketCalls        int
+	BucketExistsCalls       int
+	GetBucketOwnerCalls     int
+	ChangeBucketOwnerCalls  int
+	CreateUserCalls         int
+	DeleteUserCalls         int
+	UpdateUserCalls         int
+	UserExistsCalls         int
+	GetBucketPolicyCalls    int
+	PutBucketPolicyCalls    int
+	DeleteBucketPolicyCalls int
+	VerifyCredentialsCalls  int
+
+	PutBucketVersioningCalls        int
+	PutObjectLockConfigurationCalls int
+	PutBucketLifecycleCalls         int
+	DeleteBucketLifecycleCalls      int
+	SetBucketQuotaCalls             int
+	PutBucketCorsCalls              int
+	DeleteBucketCorsCalls           int
+	PutBucketEncryptionCalls        int
+	PutBucketTaggingCalls           int
 }
 
 type MockUser struct {
@@ -49,9 +92,18 @@ type MockUser struct {
 
 func NewMockBackend(endpointURL string) *MockBackend {
 	return &MockBackend{
-		EndpointURL: endpointURL,
-		Buckets:     make(map[string]string),
-		Users:       make(map[string]*MockUser),
+		EndpointURL:     endpointURL,
+		Buckets:         make(map[string]string),
+		Users:           make(map[string]*MockUser),
+		NonEmptyBuckets: make(map[string]bool),
+		Policies:        make(map[string]string),
+		Versioning:      make(map[string]bool),
+		ObjectLocks:     make(map[string]*ObjectLockConfiguration),
+		Lifecycles:      make(map[string][]LifecycleRule),
+		Quotas:          make(map[string]BucketQuota),
+		CORS:            make(map[string][]CORSRule),
+		Encryption:      make(map[string]BucketEncryption),
+		Tags:            make(map[string]map[string]string),
 	}
 }
 
@@ -83,6 +135,20 @@ func (m *MockBackend) CreateBucket(ctx context.Context, bucketName string, owner
 	return nil
 }
 
+// CreateBucketWithObjectLock creates a bucket with object lock and versioning enabled.
+// It is counted in CreateBucketCalls.
+func (m *MockBackend) CreateBucketWithObjectLock(ctx context.Context, bucketName string, owner *string) error {
+	if err := m.CreateBucket(ctx, bucketName, owner); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ObjectLocks[bucketName] = &ObjectLockConfiguration{}
+	m.Versioning[bucketName] = true
+	return nil
+}
+
 func (m *MockBackend) DeleteBucket(ctx context.Context, bucketName string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -96,10 +162,31 @@ func (m *MockBackend) DeleteBucket(ctx context.Context, bucketName string) error
 		return fmt.Errorf("bucket %s does not exist", bucketName)
 	}
 
+	if m.NonEmptyBuckets[bucketName] {
+		return fmt.Errorf("%w: %s", ErrBucketNotEmpty, bucketName)
+	}
+
 	delete(m.Buckets, bucketName)
 	return nil
 }
 
+func (m *MockBackend) EmptyBucket(ctx context.Context, bucketName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.EmptyBucketCalls++
+
+	if m.EmptyBucketError != nil {
+		return m.EmptyBucketError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	delete(m.NonEmptyBuckets, bucketName)
+	return nil
+}
+
 func (m *MockBackend) BucketExists(ctx context.Context, bucketName string) (bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -227,6 +314,264 @@ func (m *MockBackend) UserExists(ctx context.Context, accessKey string) (bool, e
 	return exists, nil
 }
 
+func (m *MockBackend) GetBucketPolicy(ctx context.Context, bucketName string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.GetBucketPolicyCalls++
+
+	if m.GetBucketPolicyError != nil {
+		return "", m.GetBucketPolicyError
+	}
+
+	return m.Policies[bucketName], nil
+}
+
+func (m *MockBackend) PutBucketPolicy(ctx context.Context, bucketName, policy string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketPolicyCalls++
+
+	if m.PutBucketPolicyError != nil {
+		return m.PutBucketPolicyError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.Policies[bucketName] = policy
+	return nil
+}
+
+func (m *MockBackend) DeleteBucketPolicy(ctx context.Context, bucketName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DeleteBucketPolicyCalls++
+
+	delete(m.Policies, bucketName)
+	return nil
+}
+
+func (m *MockBackend) GetBucketVersioning(ctx context.Context, bucketName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.Versioning[bucketName], nil
+}
+
+func (m *MockBackend) PutBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketVersioningCalls++
+
+	if m.PutBucketVersioningError != nil {
+		return m.PutBucketVersioningError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.Versioning[bucketName] = enabled
+	return nil
+}
+
+func (m *MockBackend) GetObjectLockConfiguration(ctx context.Context, bucketName string) (*ObjectLockConfiguration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config, ok := m.ObjectLocks[bucketName]
+	if !ok {
+		return nil, nil
+	}
+	copied := *config
+	return &copied, nil
+}
+
+// PutObjectLockConfiguration fails for buckets not created with object lock, like most backends
+func (m *MockBackend) PutObjectLockConfiguration(ctx context.Context, bucketName string, config ObjectLockConfiguration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutObjectLockConfigurationCalls++
+
+	if m.PutObjectLockConfigurationError != nil {
+		return m.PutObjectLockConfigurationError
+	}
+
+	if _, exists := m.ObjectLocks[bucketName]; !exists {
+		return fmt.Errorf("object lock is not enabled on bucket %s", bucketName)
+	}
+
+	m.ObjectLocks[bucketName] = &config
+	return nil
+}
+
+func (m *MockBackend) GetBucketLifecycle(ctx context.Context, bucketName string) ([]LifecycleRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]LifecycleRule(nil), m.Lifecycles[bucketName]...), nil
+}
+
+func (m *MockBackend) PutBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketLifecycleCalls++
+
+	if m.PutBucketLifecycleError != nil {
+		return m.PutBucketLifecycleError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.Lifecycles[bucketName] = append([]LifecycleRule(nil), rules...)
+	return nil
+}
+
+func (m *MockBackend) DeleteBucketLifecycle(ctx context.Context, bucketName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DeleteBucketLifecycleCalls++
+
+	delete(m.Lifecycles, bucketName)
+	return nil
+}
+
+func (m *MockBackend) GetBucketQuota(ctx context.Context, bucketName string) (BucketQuota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.Quotas[bucketName], nil
+}
+
+func (m *MockBackend) SetBucketQuota(ctx context.Context, bucketName string, quota BucketQuota) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SetBucketQuotaCalls++
+
+	if m.SetBucketQuotaError != nil {
+		return m.SetBucketQuotaError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	if quota.IsZero() {
+		delete(m.Quotas, bucketName)
+	} else {
+		m.Quotas[bucketName] = quota
+	}
+	return nil
+}
+
+func (m *MockBackend) SupportsObjectQuota() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return !m.NoObjectQuota
+}
+
+func (m *MockBackend) GetBucketCors(ctx context.Context, bucketName string) ([]CORSRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]CORSRule(nil), m.CORS[bucketName]...), nil
+}
+
+func (m *MockBackend) PutBucketCors(ctx context.Context, bucketName string, rules []CORSRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketCorsCalls++
+
+	if m.PutBucketCorsError != nil {
+		return m.PutBucketCorsError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.CORS[bucketName] = append([]CORSRule(nil), rules...)
+	return nil
+}
+
+func (m *MockBackend) DeleteBucketCors(ctx context.Context, bucketName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DeleteBucketCorsCalls++
+
+	delete(m.CORS, bucketName)
+	return nil
+}
+
+func (m *MockBackend) GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	encryption, exists := m.Encryption[bucketName]
+	if !exists {
+		return nil, nil
+	}
+	return &encryption, nil
+}
+
+func (m *MockBackend) PutBucketEncryption(ctx context.Context, bucketName string, encryption BucketEncryption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketEncryptionCalls++
+
+	if m.PutBucketEncryptionError != nil {
+		return m.PutBucketEncryptionError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.Encryption[bucketName] = encryption
+	return nil
+}
+
+func (m *MockBackend) GetBucketTagging(ctx context.Context, bucketName string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return maps.Clone(m.Tags[bucketName]), nil
+}
+
+func (m *MockBackend) PutBucketTagging(ctx context.Context, bucketName string, tags map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PutBucketTaggingCalls++
+
+	if m.PutBucketTaggingError != nil {
+		return m.PutBucketTaggingError
+	}
+
+	if _, exists := m.Buckets[bucketName]; !exists {
+		return fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	m.Tags[bucketName] = maps.Clone(tags)
+	return nil
+}
+
+func (m *MockBackend) VerifyCredentials(ctx context.Context, accessKey, secretKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.VerifyCredentialsCalls++
+
+	if m.VerifyCredentialsError != nil {
+		return false, m.VerifyCredentialsError
+	}
+
+	user, exists := m.Users[accessKey]
+	return exists && user.SecretKey == secretKey, nil
+}
+
 func (m *MockBackend) GetEndpointURL() string {
 	return m.EndpointURL
 }
@@ -238,10 +583,21 @@ func (m *MockBackend) Reset() {
 
 	m.Buckets = make(map[string]string)
 	m.Users = make(map[string]*MockUser)
+	m.NonEmptyBuckets = make(map[string]bool)
+	m.Policies = make(map[string]string)
+	m.Versioning = make(map[string]bool)
+	m.ObjectLocks = make(map[string]*ObjectLockConfiguration)
+	m.Lifecycles = make(map[string][]LifecycleRule)
+	m.Quotas = make(map[string]BucketQuota)
+	m.CORS = make(map[string][]CORSRule)
+	m.Encryption = make(map[string]BucketEncryption)
+	m.Tags = make(map[string]map[string]string)
+	m.NoObjectQuota = false
 
 	m.TestConnectionError = nil
 	m.CreateBucketError = nil
 	m.DeleteBucketError = nil
+	m.EmptyBucketError = nil
 	m.BucketExistsError = nil
 	m.GetBucketOwnerError = nil
 	m.ChangeBucketOwnerError = nil
@@ -249,10 +605,21 @@ func (m *MockBackend) Reset() {
 	m.DeleteUserError = nil
 	m.UpdateUserError = nil
 	m.UserExistsError = nil
+	m.GetBucketPolicyError = nil
+	m.PutBucketPolicyError = nil
+	m.VerifyCredentialsError = nil
+	m.PutBucketVersioningError = nil
+	m.PutObjectLockConfigurationError = nil
+	m.PutBucketLifecycleError = nil
+	m.SetBucketQuotaError = nil
+	m.PutBucketCorsError = nil
+	m.PutBucketEncryptionError = nil
+	m.PutBucketTaggingError = nil
 
 	m.TestConnectionCalls = 0
 	m.CreateBucketCalls = 0
 	m.DeleteBucketCalls = 0
+	m.EmptyBucketCalls = 0
 	m.BucketExistsCalls = 0
 	m.GetBucketOwnerCalls = 0
 	m.ChangeBucketOwnerCalls = 0
@@ -260,4 +627,17 @@ func (m *MockBackend) Reset() {
 	m.DeleteUserCalls = 0
 	m.UpdateUserCalls = 0
 	m.UserExistsCalls = 0
+	m.GetBucketPolicyCalls = 0
+	m.PutBucketPolicyCalls = 0
+	m.DeleteBucketPolicyCalls = 0
+	m.VerifyCredentialsCalls = 0
+	m.PutBucketVersioningCalls = 0
+	m.PutObjectLockConfigurationCalls = 0
+	m.PutBucketLifecycleCalls = 0
+	m.DeleteBucketLifecycleCalls = 0
+	m.SetBucketQuotaCalls = 0
+	m.PutBucketCorsCalls = 0
+	m.DeleteBucketCorsCalls = 0
+	m.PutBucketEncryptionCalls = 0
+	m.PutBucketTaggingCalls = 0
 }
@@ -0,0 +1,167 @@
+package backends
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BucketPolicyPreset names a generated bucket policy granting access to a single access key
+type BucketPolicyPreset string
+
+const (
+	// BucketPolicyReadOnly allows listing the bucket and reading objects
+	BucketPolicyReadOnly BucketPolicyPreset = "read-only"
+	// BucketPolicyReadWrite allows listing the bucket and reading, writing and deleting objects
+	BucketPolicyReadWrite BucketPolicyPreset = "read-write"
+	// BucketPolicyPublicRead allows anonymous reads of objects
+	BucketPolicyPublicRead BucketPolicyPreset = "public-read"
+)
+
+var (
+	readOnlyActions = []string{
+		"s3:GetBucketLocation",
+		"s3:ListBucket",
+		"s3:GetObject",
+	}
+	readWriteActions = append(append([]string{}, readOnlyActions...),
+		"s3:ListBucketMultipartUploads",
+		"s3:ListMultipartUploadParts",
+		"s3:AbortMultipartUpload",
+		"s3:PutObject",
+		"s3:DeleteObject",
+	)
+)
+
+// policyStatementIDPrefix marks statements generated by the operator
+const policyStatementIDPrefix = "S3ResourceOperator"
+
+// policyDocument is an S3 bucket policy. Statements are kept raw so that
+// statements written by others survive a merge unchanged.
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []json.RawMessage `json:"Statement"`
+}
+
+// policyStatement is a statement generated by the operator
+type policyStatement struct {
+	Sid       string              `json:"Sid"`
+	Effect    string              `json:"Effect"`
+	Principal map[string][]string `json:"Principal"`
+	Action    []string            `json:"Action"`
+	Resource  []string            `json:"Resource"`
+}
+
+// ParseBucketPolicyPreset validates a bucket policy preset name
+func ParseBucketPolicyPreset(value string) (BucketPolicyPreset, error) {
+	switch preset := BucketPolicyPreset(value); preset {
+	case BucketPolicyReadOnly, BucketPolicyReadWrite, BucketPolicyPublicRead:
+		return preset, nil
+	default:
+		return "", fmt.Errorf("invalid bucket policy %q (valid: %s, %s, %s or a JSON policy document)",
+			value, BucketPolicyReadOnly, BucketPolicyReadWrite, BucketPolicyPublicRead)
+	}
+}
+
+// IsRawBucketPolicy reports whether a bucket policy field holds a JSON document rather than a preset
+func IsRawBucketPolicy(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "{")
+}
+
+// BucketPolicyStatementID returns the statement ID used for an access key's generated statement
+func BucketPolicyStatementID(accessKey string) string {
+	hash := sha256.Sum256([]byte(accessKey))
+	return policyStatementIDPrefix + hex.EncodeToString(hash[:8])
+}
+
+// MergeBucketPolicy replaces the statement generated for accessKey in an existing policy
+// with one for the given preset. An empty preset only removes the statement. The result is
+// an empty string if no statements remain.
+func MergeBucketPolicy(existing, bucketName, accessKey string, preset BucketPolicyPreset) (string, error) {
+	doc := policyDocument{Version: "2012-10-17"}
+	if strings.TrimSpace(existing) != "" {
+		if err := json.Unmarshal([]byte(existing), &doc); err != nil {
+			return "", fmt.Errorf("failed to parse existing bucket policy: %w", err)
+		}
+	}
+
+	sid := BucketPolicyStatementID(accessKey)
+	statements := make([]json.RawMessage, 0, len(doc.Statement)+1)
+	for _, raw := range doc.Statement {
+		var stmt struct {
+			Sid string `json:"Sid"`
+		}
+		if json.Unmarshal(raw, &stmt) == nil && stmt.Sid == sid {
+			continue
+		}
+		statements = append(statements, raw)
+	}
+
+	if preset != "" {
+		stmt := generatePolicyStatement(sid, bucketName, accessKey, preset)
+		raw, err := json.Marshal(stmt)
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, raw)
+	}
+
+	if len(statements) == 0 {
+		return "", nil
+	}
+
+	doc.Statement = statements
+	result, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// HasBucketPolicyStatement reports whether a policy contains the statement generated for accessKey
+func HasBucketPolicyStatement(policy, accessKey string) bool {
+	if strings.TrimSpace(policy) == "" {
+		return false
+	}
+
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return false
+	}
+
+	sid := BucketPolicyStatementID(accessKey)
+	for _, raw := range doc.Statement {
+		var stmt struct {
+			Sid string `json:"Sid"`
+		}
+		if json.Unmarshal(raw, &stmt) == nil && stmt.Sid == sid {
+			return true
+		}
+	}
+	return false
+}
+
+func generatePolicyStatement(sid, bucketName, accessKey string, preset BucketPolicyPreset) policyStatement {
+	bucketARN := "arn:aws:s3:::" + bucketName
+	stmt := policyStatement{
+		Sid:       sid,
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": {accessKey}},
+		Resource:  []string{bucketARN, bucketARN + "/*"},
+	}
+
+	switch preset {
+	case BucketPolicyReadOnly:
+		stmt.Action = readOnlyActions
+	case BucketPolicyReadWrite:
+		stmt.Action = readWriteActions
+	case BucketPolicyPublicRead:
+		stmt.Principal = map[string][]string{"AWS": {"*"}}
+		stmt.Action = []string{"s3:GetObject"}
+		stmt.Resource = []string{bucketARN + "/*"}
+	}
+
+	return stmt
+}
@@ -0,0 +1,170 @@
+package backends
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// BackendConfig describes one backend of a registry
+type BackendConfig struct {
+	// Name identifies the backend in the backend field of secrets
+	Name string `json:"name"`
+	// Type is the backend implementation (versitygw, minio, garage)
+	Type             string `json:"type"`
+	EndpointURL      string `json:"endpointURL"`
+	AccessKey        string `json:"accessKey"`
+	SecretKey        string `json:"secretKey"`
+	AdminEndpointURL string `json:"adminEndpointURL,omitempty"`
+	AdminToken       string `json:"adminToken,omitempty"`
+
+	// Default routes secrets without a backend or known endpoint URL to this backend
+	Default bool `json:"default,omitempty"`
+}
+
+// Validate checks that the configuration is complete for its backend type
+func (c BackendConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("backend has no name")
+	}
+	if c.EndpointURL == "" || c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("backend %s: endpoint URL, access key and secret key are required", c.Name)
+	}
+	if c.Type == "garage" && (c.AdminEndpointURL == "" || c.AdminToken == "") {
+		return fmt.Errorf("backend %s: the garage backend requires an admin endpoint URL and admin token", c.Name)
+	}
+	return nil
+}
+
+// registryFile is the format of a backend registry configuration file
+type registryFile struct {
+	Backends []BackendConfig `json:"backends"`
+}
+
+// LoadRegistryConfig reads backend configurations from a YAML or JSON file
+func LoadRegistryConfig(path string) ([]BackendConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backend configuration: %w", err)
+	}
+
+	var file registryFile
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse backend configuration %s: %w", path, err)
+	}
+	return file.Backends, nil
+}
+
+// BackendConfigFromSecret builds a backend configuration from the data of an operator
+// secret. The keys match the operator's environment variables.
+func BackendConfigFromSecret(name string, data map[string][]byte) BackendConfig {
+	config := BackendConfig{
+		Name:             name,
+		Type:             string(data["BACKEND_NAME"]),
+		EndpointURL:      string(data["S3_ENDPOINT_URL"]),
+		AccessKey:        string(data["ROOT_ACCESS_KEY"]),
+		SecretKey:        string(data["ROOT_SECRET_KEY"]),
+		AdminEndpointURL: string(data["ADMIN_ENDPOINT_URL"]),
+		AdminToken:       string(data["ADMIN_TOKEN"]),
+	}
+	if config.Type == "" {
+		config.Type = "versitygw"
+	}
+	return config
+}
+
+// Registry holds the backends managed by the operator, keyed by name and endpoint URL.
+// A nil registry contains no backends.
+type Registry struct {
+	backends    map[string]Backend
+	names       []string
+	defaultName string
+}
+
+// NewRegistry creates an empty backend registry
+func NewRegistry() *Registry {
+	return &Registry{backends: make(map[string]Backend)}
+}
+
+// Add creates a backend from its configuration and registers it. The first backend
+// becomes the default unless a later one is marked as default.
+func (r *Registry) Add(config BackendConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	backend, err := NewBackend(config.Type, Config{
+		EndpointURL:      config.EndpointURL,
+		AccessKey:        config.AccessKey,
+		SecretKey:        config.SecretKey,
+		AdminEndpointURL: config.AdminEndpointURL,
+		AdminToken:       config.AdminToken,
+	})
+	if err != nil {
+		return fmt.Errorf("backend %s: %w", config.Name, err)
+	}
+	return r.Register(config.Name, backend, config.Default)
+}
+
+// Register adds an existing backend under a name
+func (r *Registry) Register(name string, backend Backend, isDefault bool) error {
+	if _, exists := r.backends[name]; exists {
+		return fmt.Errorf("backend %s is configured more than once", name)
+	}
+	for _, other := range r.names {
+		if SameEndpoint(r.backends[other].GetEndpointURL(), backend.GetEndpointURL()) {
+			return fmt.Errorf("backends %s and %s share the endpoint %s", other, name, backend.GetEndpointURL())
+		}
+	}
+
+	r.backends[name] = backend
+	r.names = append(r.names, name)
+	if r.defaultName == "" || isDefault {
+		r.defaultName = name
+	}
+	return nil
+}
+
+// Get returns the backend with the given name
+func (r *Registry) Get(name string) (Backend, bool) {
+	if r == nil {
+		return nil, false
+	}
+	backend, ok := r.backends[name]
+	return backend, ok
+}
+
+// ForEndpoint returns the backend serving the given endpoint URL
+func (r *Registry) ForEndpoint(endpointURL string) (Backend, bool) {
+	if r == nil {
+		return nil, false
+	}
+	for _, name := range r.names {
+		if SameEndpoint(r.backends[name].GetEndpointURL(), endpointURL) {
+			return r.backends[name], true
+		}
+	}
+	return nil, false
+}
+
+// Default returns the default backend, or nil if the registry is empty
+func (r *Registry) Default() Backend {
+	if r == nil {
+		return nil
+	}
+	return r.backends[r.defaultName]
+}
+
+// Names returns the names of all backends in registration order
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	return append([]string(nil), r.names...)
+}
+
+// SameEndpoint reports whether two endpoint URLs refer to the same endpoint
+func SameEndpoint(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -3,12 +3,11 @@ package backends
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -29,6 +28,24 @@ type VersityGW struct {
 	signer      *v4.Signer
 }
 
+// versityAccount is the request body of the create-user admin API
+type versityAccount struct {
+	XMLName xml.Name `xml:"Account"`
+	Access  string   `xml:"Access"`
+	Secret  string   `xml:"Secret"`
+	Role    string   `xml:"Role"`
+	UserID  *int     `xml:"UserID,omitempty"`
+	GroupID *int     `xml:"GroupID,omitempty"`
+}
+
+// versityMutableProps is the request body of the update-user admin API
+type versityMutableProps struct {
+	XMLName xml.Name `xml:"MutableProps"`
+	Secret  *string  `xml:"Secret,omitempty"`
+	UserID  *int     `xml:"UserID,omitempty"`
+	GroupID *int     `xml:"GroupID,omitempty"`
+}
+
 // NewVersityGW creates a new VersityGW backend
 func NewVersityGW(config Config) *VersityGW {
 	sess := session.Must(session.NewSession(&aws.Config{
@@ -76,6 +93,14 @@ func (v *VersityGW) TestConnection(ctx context.Context) error {
 }
 
 func (v *VersityGW) CreateBucket(ctx context.Context, bucketName string, owner *string) error {
+	return v.createBucket(ctx, bucketName, owner, false)
+}
+
+func (v *VersityGW) CreateBucketWithObjectLock(ctx context.Context, bucketName string, owner *string) error {
+	return v.createBucket(ctx, bucketName, owner, true)
+}
+
+func (v *VersityGW) createBucket(ctx context.Context, bucketName string, owner *string, objectLock bool) error {
 	exists, err := v.BucketExists(ctx, bucketName)
 	if err != nil {
 		return err
@@ -85,14 +110,11 @@ func (v *VersityGW) CreateBucket(ctx context.Context, bucketName string, owner *
 		return nil
 	}
 
-	_, err = v.s3Client.CreateBucketWithContext(ctx, &s3.CreateBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
+	if err := createS3Bucket(ctx, v.s3Client, bucketName, objectLock); err != nil {
 		return fmt.Errorf("failed to create bucket: %w", err)
 	}
 
-	ctrl.Log.WithName("versitygw").Info("Created bucket", "bucket", bucketName)
+	ctrl.Log.WithName("versitygw").Info("Created bucket", "bucket", bucketName, "objectLock", objectLock)
 
 	if owner != nil {
 		return v.ChangeBucketOwner(ctx, bucketName, *owner)
@@ -102,10 +124,7 @@ func (v *VersityGW) CreateBucket(ctx context.Context, bucketName string, owner *
 }
 
 func (v *VersityGW) DeleteBucket(ctx context.Context, bucketName string) error {
-	_, err := v.s3Client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
+	if err := deleteS3Bucket(ctx, v.s3Client, bucketName); err != nil {
 		return fmt.Errorf("failed to delete bucket: %w", err)
 	}
 
@@ -113,20 +132,22 @@ func (v *VersityGW) DeleteBucket(ctx context.Context, bucketName string) error {
 	return nil
 }
 
-func (v *VersityGW) BucketExists(ctx context.Context, bucketName string) (bool, error) {
-	_, err := v.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
-	})
-	if err != nil {
-		// Check if it's a "not found" error
-		return false, nil
+func (v *VersityGW) EmptyBucket(ctx context.Context, bucketName string) error {
+	if err := emptyS3Bucket(ctx, v.s3Client, bucketName); err != nil {
+		return fmt.Errorf("failed to empty bucket: %w", err)
 	}
-	return true, nil
+
+	ctrl.Log.WithName("versitygw").Info("Emptied bucket", "bucket", bucketName)
+	return nil
+}
+
+func (v *VersityGW) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	return s3BucketExists(ctx, v.s3Client, bucketName)
 }
 
 func (v *VersityGW) GetBucketOwner(ctx context.Context, bucketName string) (string, error) {
-	url := fmt.Sprintf("%s/%s?acl", v.endpointURL, bucketName)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	endpoint := fmt.Sprintf("%s/%s?acl", v.endpointURL, url.PathEscape(bucketName))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return "", err
 	}
@@ -164,9 +185,10 @@ func (v *VersityGW) GetBucketOwner(ctx context.Context, bucketName string) (stri
 }
 
 func (v *VersityGW) ChangeBucketOwner(ctx context.Context, bucketName, newOwner string) error {
-	url := fmt.Sprintf("%s/change-bucket-owner/?bucket=%s&owner=%s", v.endpointURL, bucketName, newOwner)
+	query := url.Values{"bucket": {bucketName}, "owner": {newOwner}}
+	endpoint := fmt.Sprintf("%s/change-bucket-owner/?%s", v.endpointURL, query.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, nil)
 	if err != nil {
 		return err
 	}
@@ -190,6 +212,126 @@ func (v *VersityGW) ChangeBucketOwner(ctx context.Context, bucketName, newOwner
 	return nil
 }
 
+func (v *VersityGW) GetBucketVersioning(ctx context.Context, bucketName string) (bool, error) {
+	enabled, err := getS3BucketVersioning(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get bucket versioning: %w", err)
+	}
+	return enabled, nil
+}
+
+func (v *VersityGW) PutBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	if err := putS3BucketVersioning(ctx, v.s3Client, bucketName, enabled); err != nil {
+		return fmt.Errorf("failed to put bucket versioning: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated bucket versioning", "bucket", bucketName, "enabled", enabled)
+	return nil
+}
+
+func (v *VersityGW) GetObjectLockConfiguration(ctx context.Context, bucketName string) (*ObjectLockConfiguration, error) {
+	config, err := getS3ObjectLockConfiguration(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+	return config, nil
+}
+
+func (v *VersityGW) PutObjectLockConfiguration(ctx context.Context, bucketName string, config ObjectLockConfiguration) error {
+	if err := putS3ObjectLockConfiguration(ctx, v.s3Client, bucketName, config); err != nil {
+		return fmt.Errorf("failed to put object lock configuration: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated object lock configuration", "bucket", bucketName, "mode", config.Mode, "days", config.Days)
+	return nil
+}
+
+func (v *VersityGW) GetBucketPolicy(ctx context.Context, bucketName string) (string, error) {
+	policy, err := getS3BucketPolicy(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (v *VersityGW) PutBucketPolicy(ctx context.Context, bucketName, policy string) error {
+	if err := putS3BucketPolicy(ctx, v.s3Client, bucketName, policy); err != nil {
+		return fmt.Errorf("failed to put bucket policy: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated bucket policy", "bucket", bucketName)
+	return nil
+}
+
+func (v *VersityGW) DeleteBucketPolicy(ctx context.Context, bucketName string) error {
+	if err := deleteS3BucketPolicy(ctx, v.s3Client, bucketName); err != nil {
+		return fmt.Errorf("failed to delete bucket policy: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Deleted bucket policy", "bucket", bucketName)
+	return nil
+}
+
+func (v *VersityGW) GetBucketCors(ctx context.Context, bucketName string) ([]CORSRule, error) {
+	rules, err := getS3BucketCors(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket cors: %w", err)
+	}
+	return rules, nil
+}
+
+func (v *VersityGW) PutBucketCors(ctx context.Context, bucketName string, rules []CORSRule) error {
+	if err := putS3BucketCors(ctx, v.s3Client, bucketName, rules); err != nil {
+		return fmt.Errorf("failed to put bucket cors: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated bucket cors", "bucket", bucketName, "rules", len(rules))
+	return nil
+}
+
+func (v *VersityGW) DeleteBucketCors(ctx context.Context, bucketName string) error {
+	if err := deleteS3BucketCors(ctx, v.s3Client, bucketName); err != nil {
+		return fmt.Errorf("failed to delete bucket cors: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Deleted bucket cors", "bucket", bucketName)
+	return nil
+}
+
+func (v *VersityGW) GetBucketEncryption(ctx context.Context, bucketName string) (*BucketEncryption, error) {
+	encryption, err := getS3BucketEncryption(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket encryption: %w", err)
+	}
+	return encryption, nil
+}
+
+func (v *VersityGW) PutBucketEncryption(ctx context.Context, bucketName string, encryption BucketEncryption) error {
+	if err := putS3BucketEncryption(ctx, v.s3Client, bucketName, encryption); err != nil {
+		return fmt.Errorf("failed to put bucket encryption: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated bucket encryption", "bucket", bucketName, "algorithm", encryption.Algorithm)
+	return nil
+}
+
+func (v *VersityGW) GetBucketTagging(ctx context.Context, bucketName string) (map[string]string, error) {
+	tags, err := getS3BucketTagging(ctx, v.s3Client, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket tagging: %w", err)
+	}
+	return tags, nil
+}
+
+func (v *VersityGW) PutBucketTagging(ctx context.Context, bucketName string, tags map[string]string) error {
+	if err := putS3BucketTagging(ctx, v.s3Client, bucketName, tags); err != nil {
+		return fmt.Errorf("failed to put bucket tagging: %w", err)
+	}
+
+	ctrl.Log.WithName("versitygw").Info("Updated bucket tags", "bucket", bucketName, "tags", len(tags))
+	return nil
+}
+
 func (v *VersityGW) CreateUser(ctx context.Context, accessKey, secretKey string, role *string, userID, groupID *int) error {
 	log := ctrl.Log.WithName("versitygw")
 	exists, err := v.UserExists(ctx, accessKey)
@@ -201,25 +343,25 @@ func (v *VersityGW) CreateUser(ctx context.Context, accessKey, secretKey string,
 		return nil
 	}
 
-	url := fmt.Sprintf("%s/create-user", v.endpointURL)
+	endpoint := fmt.Sprintf("%s/create-user", v.endpointURL)
 
-	userPayload := fmt.Sprintf(`<Account><Access>%s</Access><Secret>%s</Secret>`, accessKey, secretKey)
-	if role != nil {
-		userPayload += fmt.Sprintf(`<Role>%s</Role>`, *role)
-	} else {
-		userPayload += `<Role>user</Role>`
-	}
-	if userID != nil {
-		userPayload += fmt.Sprintf(`<UserID>%d</UserID>`, *userID)
+	account := versityAccount{
+		Access:  accessKey,
+		Secret:  secretKey,
+		Role:    "user",
+		UserID:  userID,
+		GroupID: groupID,
 	}
-	if groupID != nil {
-		userPayload += fmt.Sprintf(`<GroupID>%d</GroupID>`, *groupID)
+	if role != nil {
+		account.Role = *role
 	}
-	userPayload += `</Account>`
 
-	payloadBytes := []byte(userPayload)
+	payloadBytes, err := xml.Marshal(account)
+	if err != nil {
+		return err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return err
 	}
@@ -245,9 +387,10 @@ func (v *VersityGW) CreateUser(ctx context.Context, accessKey, secretKey string,
 }
 
 func (v *VersityGW) DeleteUser(ctx context.Context, accessKey string) error {
-	url := fmt.Sprintf("%s/delete-user?access=%s", v.endpointURL, accessKey)
+	query := url.Values{"access": {accessKey}}
+	endpoint := fmt.Sprintf("%s/delete-user?%s", v.endpointURL, query.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, nil)
 	if err != nil {
 		return err
 	}
@@ -272,23 +415,19 @@ func (v *VersityGW) DeleteUser(ctx context.Context, accessKey string) error {
 }
 
 func (v *VersityGW) UpdateUser(ctx context.Context, accessKey string, secretKey *string, userID, groupID *int) error {
-	url := fmt.Sprintf("%s/update-user?access=%s", v.endpointURL, accessKey)
+	query := url.Values{"access": {accessKey}}
+	endpoint := fmt.Sprintf("%s/update-user?%s", v.endpointURL, query.Encode())
 
-	userPayload := `<MutableProps>`
-	if secretKey != nil {
-		userPayload += fmt.Sprintf(`<Secret>%s</Secret>`, *secretKey)
-	}
-	if userID != nil {
-		userPayload += fmt.Sprintf(`<UserID>%d</UserID>`, *userID)
-	}
-	if groupID != nil {
-		userPayload += fmt.Sprintf(`<GroupID>%d</GroupID>`, *groupID)
+	payloadBytes, err := xml.Marshal(versityMutableProps{
+		Secret:  secretKey,
+		UserID:  userID,
+		GroupID: groupID,
+	})
+	if err != nil {
+		return err
 	}
-	userPayload += `</MutableProps>`
 
-	payloadBytes := []byte(userPayload)
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return err
 	}
@@ -329,8 +468,8 @@ func (v *VersityGW) UserExists(ctx context.Context, accessKey string) (bool, err
 }
 
 func (v *VersityGW) listBucketsRaw(ctx context.Context) ([]string, error) {
-	url := fmt.Sprintf("%s/list-buckets", v.endpointURL)
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
+	endpoint := fmt.Sprintf("%s/list-buckets", v.endpointURL)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -374,8 +513,8 @@ func (v *VersityGW) listBucketsRaw(ctx context.Context) ([]string, error) {
 }
 
 func (v *VersityGW) listUsersRaw(ctx context.Context) ([]string, error) {
-	url := fmt.Sprintf("%s/list-users", v.endpointURL)
-	req, err := http.NewRequestWithContext(ctx, "PATCH", url, nil)
+	endpoint := fmt.Sprintf("%s/list-users", v.endpointURL)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -418,16 +557,9 @@ func (v *VersityGW) listUsersRaw(ctx context.Context) ([]string, error) {
 }
 
 func (v *VersityGW) signRequest(req *http.Request, body []byte) error {
-	var bodyReader io.ReadSeeker
-	if body != nil {
-		bodyReader = bytes.NewReader(body)
-		hash := sha256.Sum256(body)
-		req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
-	} else {
-		bodyReader = bytes.NewReader([]byte{})
-		req.Header.Set("X-Amz-Content-Sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
-	}
-
-	_, err := v.signer.Sign(req, bodyReader, "s3", "us-east-1", time.Now())
-	return err
+	return signV4Request(v.signer, req, body)
+}
+
+func (v *VersityGW) VerifyCredentials(ctx context.Context, accessKey, secretKey string) (bool, error) {
+	return verifyS3Credentials(ctx, v.endpointURL, accessKey, secretKey)
 }
@@ -0,0 +1,176 @@
+package backends
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// fakeVersityGW is a minimal stand-in for the VersityGW admin API that records the
+// decoded arguments of every request
+type fakeVersityGW struct {
+	mu       sync.Mutex
+	accounts map[string]versityAccount
+	updates  map[string]versityMutableProps
+	owners   map[string]string
+	deleted  []string
+	queries  []url.Values
+}
+
+func newFakeVersityGW(t *testing.T) (*fakeVersityGW, *httptest.Server) {
+	f := &fakeVersityGW{
+		accounts: make(map[string]versityAccount),
+		updates:  make(map[string]versityMutableProps),
+		owners:   make(map[string]string),
+	}
+	server := httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeVersityGW) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	f.queries = append(f.queries, query)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/list-users":
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, "<ListUserAccountsResult></ListUserAccountsResult>")
+	case "/create-user":
+		var account versityAccount
+		if err := xml.Unmarshal(body, &account); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.accounts[account.Access] = account
+	case "/update-user":
+		var props versityMutableProps
+		if err := xml.Unmarshal(body, &props); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.updates[query.Get("access")] = props
+	case "/delete-user":
+		f.deleted = append(f.deleted, query.Get("access"))
+	case "/change-bucket-owner/":
+		f.owners[query.Get("bucket")] = query.Get("owner")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVersityGWHostileInputs(t *testing.T) {
+	tests := []struct {
+		name      string
+		accessKey string
+		secretKey string
+		role      string
+		bucket    string
+	}{
+		{
+			name:      "plain",
+			accessKey: "user1",
+			secretKey: "secret1",
+			role:      "user",
+			bucket:    "bucket1",
+		},
+		{
+			name:      "xml injection",
+			accessKey: "user</Access><Role>admin</Role><Access>x",
+			secretKey: "secret</Secret><Role>admin</Role>",
+			role:      "user</Role><Role>admin",
+			bucket:    "bucket",
+		},
+		{
+			name:      "xml special characters",
+			accessKey: `a&b<c>"d'`,
+			secretKey: "]]><!-- -->",
+			role:      "<![CDATA[admin]]>",
+			bucket:    "bucket",
+		},
+		{
+			name:      "query injection",
+			accessKey: "user&access=root",
+			secretKey: "secret",
+			role:      "user",
+			bucket:    "bucket&owner=root",
+		},
+		{
+			name:      "spaces and unicode",
+			accessKey: "user name +é",
+			secretKey: "secret key %20",
+			role:      "user",
+			bucket:    "bucket #1?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, server := newFakeVersityGW(t)
+			backend := NewVersityGW(Config{EndpointURL: server.URL, AccessKey: "admin", SecretKey: "admin-secret"})
+			ctx := context.Background()
+			userID, groupID := 1000, 2000
+
+			if err := backend.CreateUser(ctx, tt.accessKey, tt.secretKey, &tt.role, &userID, &groupID); err != nil {
+				t.Fatalf("CreateUser failed: %v", err)
+			}
+			account, ok := fake.accounts[tt.accessKey]
+			if !ok {
+				t.Fatalf("expected account %q to be created, got %v", tt.accessKey, fake.accounts)
+			}
+			if account.Secret != tt.secretKey || account.Role != tt.role {
+				t.Errorf("unexpected account %+v", account)
+			}
+			if account.UserID == nil || *account.UserID != userID || account.GroupID == nil || *account.GroupID != groupID {
+				t.Errorf("unexpected account IDs %+v", account)
+			}
+
+			if err := backend.UpdateUser(ctx, tt.accessKey, &tt.secretKey, nil, nil); err != nil {
+				t.Fatalf("UpdateUser failed: %v", err)
+			}
+			props, ok := fake.updates[tt.accessKey]
+			if !ok {
+				t.Fatalf("expected user %q to be updated, got %v", tt.accessKey, fake.updates)
+			}
+			if props.Secret == nil || *props.Secret != tt.secretKey || props.UserID != nil || props.GroupID != nil {
+				t.Errorf("unexpected update %+v", props)
+			}
+
+			if err := backend.ChangeBucketOwner(ctx, tt.bucket, tt.accessKey); err != nil {
+				t.Fatalf("ChangeBucketOwner failed: %v", err)
+			}
+			if owner, ok := fake.owners[tt.bucket]; !ok || owner != tt.accessKey {
+				t.Errorf("expected owner of %q to be %q, got %v", tt.bucket, tt.accessKey, fake.owners)
+			}
+
+			if err := backend.DeleteUser(ctx, tt.accessKey); err != nil {
+				t.Fatalf("DeleteUser failed: %v", err)
+			}
+			if len(fake.deleted) != 1 || fake.deleted[0] != tt.accessKey {
+				t.Errorf("expected user %q to be deleted, got %v", tt.accessKey, fake.deleted)
+			}
+
+			// No request may smuggle in a second value for a parameter
+			for _, query := range fake.queries {
+				for key, values := range query {
+					if len(values) != 1 {
+						t.Errorf("parameter %s has multiple values %v", key, values)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BucketNameAnnotation holds the bucket names a secret resolved to after applying the
+// operator's bucket name template, separated by commas
+const BucketNameAnnotation = "s3-resource-operator.io/bucket-name"
+
+// Field names of additional buckets: a comma-separated list, or numbered fields like
+// bucket-name-1 and bucket-name-2
+var (
+	bucketNamesFields          = []string{"bucket-names", "BUCKET_NAMES"}
+	numberedBucketNamePrefixes = []string{"bucket-name-", "BUCKET_NAME_"}
+)
+
+// BucketNameParams are the values available to a bucket name template
+type BucketNameParams struct {
+	ClusterName string
+	Namespace   string
+	SecretName  string
+	// BucketName is the bucket-name field of the secret
+	BucketName string
+}
+
+// bucketNamePattern matches names of lowercase letters, digits, dots and hyphens that start
+// and end with a letter or digit
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`)
+
+// ValidateBucketName checks a bucket name against the S3 naming rules
+func ValidateBucketName(name string) error {
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		return fmt.Errorf("bucket name %q must be between 3 and 63 characters long", name)
+	case !bucketNamePattern.MatchString(name):
+		return fmt.Errorf("bucket name %q may only contain lowercase letters, digits, dots and hyphens, and must start and end with a letter or digit", name)
+	case strings.Contains(name, ".."), strings.Contains(name, ".-"), strings.Contains(name, "-."):
+		return fmt.Errorf("bucket name %q must not contain adjacent dots or a dot next to a hyphen", name)
+	case net.ParseIP(name) != nil:
+		return fmt.Errorf("bucket name %q must not be formatted as an IP address", name)
+	case strings.HasPrefix(name, "xn--"), strings.HasPrefix(name, "sthree-"):
+		return fmt.Errorf("bucket name %q uses a reserved prefix", name)
+	case strings.HasSuffix(name, "-s3alias"), strings.HasSuffix(name, "--ol-s3"):
+		return fmt.Errorf("bucket name %q uses a reserved suffix", name)
+	}
+	return nil
+}
+
+// ParseBucketNameTemplate parses a bucket name template such as {{.Namespace}}-{{.BucketName}}.
+// Templates referring to unknown fields are rejected.
+func ParseBucketNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("bucket-name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket name template: %w", err)
+	}
+
+	var sample strings.Builder
+	if err := tmpl.Execute(&sample, BucketNameParams{ClusterName: "cluster", Namespace: "namespace", SecretName: "secret", BucketName: "bucket"}); err != nil {
+		return nil, fmt.Errorf("invalid bucket name template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// secretBucketNames returns the bucket names listed in a secret: the bucket-name field, the
+// bucket-names list and the numbered fields in the order of their numbers
+func secretBucketNames(data map[string]string) []string {
+	var names []string
+	if name := getField(data, bucketNameFields...); name != "" {
+		names = append(names, name)
+	}
+	for name := range strings.SplitSeq(getField(data, bucketNamesFields...), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	type numbered struct {
+		n    int
+		name string
+	}
+	var fields []numbered
+	for key, value := range data {
+		for _, prefix := range numberedBucketNamePrefixes {
+			suffix, ok := strings.CutPrefix(key, prefix)
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(suffix); err == nil && n > 0 && value != "" {
+				fields = append(fields, numbered{n: n, name: value})
+			}
+		}
+	}
+	slices.SortFunc(fields, func(a, b numbered) int {
+		return cmp.Or(cmp.Compare(a.n, b.n), strings.Compare(a.name, b.name))
+	})
+	for _, field := range fields {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+// bucketNames returns the names of a secret's buckets: the names it lists passed through the
+// operator's bucket name template, if configured, without duplicates. It returns nil if the
+// secret lists no bucket.
+func (r *SecretReconciler) bucketNames(secret *corev1.Secret, data map[string]string) ([]string, error) {
+	var names []string
+	for _, name := range secretBucketNames(data) {
+		resolved, err := r.resolveBucketName(secret, name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(names, resolved) {
+			names = append(names, resolved)
+		}
+	}
+	return names, nil
+}
+
+// resolveBucketName applies the operator's bucket name template, if configured, to a bucket
+// name listed in a secret and validates the result
+func (r *SecretReconciler) resolveBucketName(secret *corev1.Secret, name string) (string, error) {
+	if r.BucketNameTemplate != nil {
+		var resolved strings.Builder
+		err := r.BucketNameTemplate.Execute(&resolved, BucketNameParams{
+			ClusterName: r.ClusterName,
+			Namespace:   secret.Namespace,
+			SecretName:  secret.Name,
+			BucketName:  name,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to apply bucket name template: %w", err)
+		}
+		name = resolved.String()
+	}
+
+	if err := ValidateBucketName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// AdoptAnnotation allows a secret or S3Bucket to take over a bucket claimed by another one
+const AdoptAnnotation = "s3-resource-operator.io/adopt"
+
+// ShareWithAnnotation lists the secrets and S3Buckets that may share the buckets a secret or
+// S3Bucket claims with the read-only or read-write bucket policy presets, as comma-separated
+// namespace/name or namespace/* entries
+const ShareWithAnnotation = "s3-resource-operator.io/share-with"
+
+// ErrBucketClaimConflict is returned for buckets claimed by another secret or S3Bucket
+var ErrBucketClaimConflict = errors.New("bucket is claimed by another secret")
+
+// Kinds of objects claiming buckets, recorded in the kind tag
+const (
+	claimKindSecret   = "Secret"
+	claimKindS3Bucket = "S3Bucket"
+)
+
+// bucketClaims checks and records which secret or S3Bucket claimed a bucket
+type bucketClaims struct {
+	// reader looks up claiming secrets and S3Buckets, bypassing the cache so secrets that left
+	// the scope are seen
+	reader        client.Reader
+	clusterName   string
+	scope         WatchScope
+	annotationKey string
+}
+
+// claims returns the bucket claims of the secrets this reconciler manages
+func (r *SecretReconciler) claims() bucketClaims {
+	return bucketClaims{reader: r.reader(), clusterName: r.ClusterName, scope: r.Scope, annotationKey: r.AnnotationKey}
+}
+
+// claimKind returns the kind recorded in the tags of the buckets an object claims
+func claimKind(obj client.Object) string {
+	if _, ok := obj.(*s3v1alpha1.S3Bucket); ok {
+		return claimKindS3Bucket
+	}
+	return claimKindSecret
+}
+
+// provenanceTags returns the tags recording the cluster and object a bucket belongs to. Buckets
+// of secrets are not tagged with a kind.
+func (c bucketClaims) provenanceTags(obj client.Object) map[string]string {
+	tags := map[string]string{
+		TagNamespace:  obj.GetNamespace(),
+		TagSecretName: obj.GetName(),
+		TagSecretUID:  string(obj.GetUID()),
+	}
+	if kind := claimKind(obj); kind != claimKindSecret {
+		tags[TagKind] = kind
+	}
+	if c.clusterName != "" {
+		tags[TagCluster] = c.clusterName
+	}
+	return tags
+}
+
+// claimBucket makes sure a secret or S3Bucket (obj) may manage an existing bucket and reports
+// whether obj holds its claim. The secret-uid tag of a bucket records the object that claimed
+// it; other objects are rejected with an error wrapping ErrBucketClaimConflict unless they carry
+// the adopt annotation or the claiming object is gone. Objects sharing access to a bucket
+// (shared) never take over a claim and need the consent of the claiming object, see
+// checkConsent. On backends without tagging the owner access key of a bucket stands in for its
+// claim, see claimByOwner.
+func (c bucketClaims) claimBucket(ctx context.Context, backend backends.Backend, recordEvent eventFunc, obj client.Object, bucketName, owner string, shared bool) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	exists, err := backend.BucketExists(ctx, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+	if !exists {
+		return true, nil
+	}
+
+	if _, ok := backend.(backends.BucketTaggingManager); !ok {
+		return claimByOwner(ctx, backend, recordEvent, obj, bucketName, owner, shared)
+	}
+
+	tags, err := bucketTags(ctx, backend, bucketName)
+	if err != nil {
+		return false, err
+	}
+	claim := tags[TagSecretUID]
+	if claim == "" || claim == string(obj.GetUID()) {
+		return true, nil
+	}
+	if shared {
+		return false, c.checkConsent(ctx, tags, obj, bucketName)
+	}
+
+	if adopt, _ := strconv.ParseBool(obj.GetAnnotations()[AdoptAnnotation]); adopt {
+		logger.Info("Taking over bucket claimed by another object", "bucket", bucketName, "claimedBy", claimedBy(tags))
+		recordEvent(corev1.EventTypeNormal, ReasonBucketAdopted, "Took over bucket %s claimed by %s", bucketName, claimedBy(tags))
+		return true, nil
+	}
+
+	stale, err := c.isStaleClaim(ctx, tags)
+	if err != nil {
+		return false, err
+	}
+	if stale {
+		logger.Info("Replacing stale bucket claim", "bucket", bucketName, "claimedBy", claimedBy(tags))
+		return true, nil
+	}
+
+	return false, fmt.Errorf("%w: bucket %s is claimed by %s, set the %s: \"true\" annotation to take it over",
+		ErrBucketClaimConflict, bucketName, claimedBy(tags), AdoptAnnotation)
+}
+
+// claimByOwner guards buckets on backends that cannot record claims in tags. Only the access key
+// owning an existing bucket may use it: other objects are rejected with an error wrapping
+// ErrBucketClaimConflict, so the bucket neither changes owner nor is shared, unless they carry
+// the adopt annotation. Sharing needs a recorded consent and is always rejected.
+func claimByOwner(ctx context.Context, backend backends.Backend, recordEvent eventFunc, obj client.Object, bucketName, owner string, shared bool) (bool, error) {
+	current, err := backend.GetBucketOwner(ctx, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get bucket owner: %w", err)
+	}
+	if current == "" || current == owner {
+		return true, nil
+	}
+
+	if shared {
+		return false, fmt.Errorf("%w: bucket %s is owned by access key %s and the backend cannot record bucket claims, so it cannot be shared",
+			ErrBucketClaimConflict, bucketName, current)
+	}
+	if adopt, _ := strconv.ParseBool(obj.GetAnnotations()[AdoptAnnotation]); adopt {
+		log.FromContext(ctx).Info("Taking over bucket owned by another access key", "bucket", bucketName, "owner", current)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketAdopted, "Took over bucket %s owned by access key %s", bucketName, current)
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: bucket %s is owned by access key %s and the backend cannot record bucket claims, set the %s: \"true\" annotation to take it over",
+		ErrBucketClaimConflict, bucketName, current, AdoptAnnotation)
+}
+
+// checkShared rejects the settings of a secret sharing a bucket claimed by another secret.
+// Such a secret may only add its own bucket policy statement; everything else is up to the
+// secret holding the claim, including the operator's default encryption.
+func (s bucketSettings) checkShared(bucketName string) error {
+	var fields []string
+	if s.versioning.isSet() {
+		fields = append(fields, "versioning")
+	}
+	if s.lifecycleRules != nil {
+		fields = append(fields, "lifecycle-rules")
+	}
+	if s.quota != nil {
+		fields = append(fields, "quota")
+	}
+	if s.corsRules != nil {
+		fields = append(fields, "cors")
+	}
+	if s.encryptionSet {
+		fields = append(fields, "encryption")
+	}
+	if s.tags != nil {
+		fields = append(fields, "bucket-tags")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: bucket %s is claimed by another secret, a secret sharing it may only set bucket-policy, not %s",
+		ErrBucketClaimConflict, bucketName, strings.Join(fields, ", "))
+}
+
+// checkConsent makes sure the object that claimed a bucket lists obj in its share-with
+// annotation. Claiming objects the operator does not manage or that no longer hold their claim
+// never consent.
+func (c bucketClaims) checkConsent(ctx context.Context, tags map[string]string, obj client.Object, bucketName string) error {
+	claimant, managed, err := c.claimant(ctx, tags)
+	if err != nil {
+		return err
+	}
+	if managed && claimant != nil && !c.isDisabled(claimant) && sharesWith(claimant, obj) {
+		return nil
+	}
+	return fmt.Errorf("%w: bucket %s is claimed by %s, which does not share it with %s/%s, add it to the %s annotation of the claiming object",
+		ErrBucketClaimConflict, bucketName, claimedBy(tags), obj.GetNamespace(), obj.GetName(), ShareWithAnnotation)
+}
+
+// sharesWith reports whether the share-with annotation of a claiming object lists obj
+func sharesWith(claimant, obj client.Object) bool {
+	for _, entry := range splitList(claimant.GetAnnotations()[ShareWithAnnotation]) {
+		if entry == obj.GetNamespace()+"/"+obj.GetName() || entry == obj.GetNamespace()+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaleClaim reports whether the object that claimed a bucket no longer manages it: it was
+// deleted, replaced by an object of the same name or, for secrets, disabled; paused secrets keep
+// their claims. Claims of other clusters or of objects outside the operator's scope are never
+// stale, the operator does not manage their objects.
+func (c bucketClaims) isStaleClaim(ctx context.Context, tags map[string]string) (bool, error) {
+	claimant, managed, err := c.claimant(ctx, tags)
+	if err != nil || !managed {
+		return false, err
+	}
+	return claimant == nil || c.isDisabled(claimant), nil
+}
+
+// claimant looks up the object that claimed a bucket. It reports whether the operator manages
+// that object, i.e. it belongs to this cluster and the operator's scope, and returns nil if the
+// object was deleted or replaced by one of the same name.
+func (c bucketClaims) claimant(ctx context.Context, tags map[string]string) (client.Object, bool, error) {
+	if tags[TagCluster] != c.clusterName || tags[TagNamespace] == "" || tags[TagSecretName] == "" {
+		return nil, false, nil
+	}
+	if !c.scope.IncludesNamespace(tags[TagNamespace]) {
+		return nil, false, nil
+	}
+
+	key := types.NamespacedName{Namespace: tags[TagNamespace], Name: tags[TagSecretName]}
+	var claimant client.Object = &corev1.Secret{}
+	if tags[TagKind] == claimKindS3Bucket {
+		claimant = &s3v1alpha1.S3Bucket{}
+	}
+	err := c.reader.Get(ctx, key, claimant)
+	if apierrors.IsNotFound(err) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get claiming %s: %w", claimedBy(tags), err)
+	}
+	if string(claimant.GetUID()) != tags[TagSecretUID] {
+		return nil, true, nil
+	}
+	if secret, ok := claimant.(*corev1.Secret); ok && !c.scope.Includes(secret) {
+		return nil, false, nil
+	}
+	return claimant, true, nil
+}
+
+// isDisabled reports whether a claiming secret was disabled; S3Buckets are never disabled
+func (c bucketClaims) isDisabled(claimant client.Object) bool {
+	secret, ok := claimant.(*corev1.Secret)
+	return ok && secretAnnotationState(secret, c.annotationKey) == stateDisabled
+}
+
+// claimedBy describes the object that claimed a bucket for logs and events
+func claimedBy(tags map[string]string) string {
+	kind := "secret"
+	if tags[TagKind] == claimKindS3Bucket {
+		kind = claimKindS3Bucket
+	}
+	name := kind + " " + tags[TagNamespace] + "/" + tags[TagSecretName]
+	if cluster := tags[TagCluster]; cluster != "" {
+		return name + " in cluster " + cluster
+	}
+	return name
+}
@@ -2,14 +2,24 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/runningman84/s3-resource-operator/pkg/backends"
 	"github.com/runningman84/s3-resource-operator/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -21,6 +31,49 @@ type SecretReconciler struct {
 	Backend         backends.Backend
 	AnnotationKey   string
 	EnforceEndpoint bool
+
+	// Scope restricts the secrets the reconciler watches. Every secret is watched if empty.
+	Scope WatchScope
+
+	// APIReader reads from the API server, bypassing the cache, which only holds the secrets in
+	// Scope. It looks up the secrets claiming buckets and secrets that left Scope. Client is used
+	// if nil.
+	APIReader client.Reader
+
+	// Backends routes secrets to a backend by their backend or endpoint-url field. Secrets
+	// without either use Backend. Only Backend is used if nil.
+	Backends *backends.Registry
+
+	// DeletionPolicy is applied to secrets without a deletion policy annotation.
+	// An empty value behaves like DeletionPolicyRetain.
+	DeletionPolicy DeletionPolicy
+
+	// ClusterName identifies this cluster in the tags of the buckets it manages. Buckets are not
+	// tagged with a cluster if empty.
+	ClusterName string
+
+	// BucketNameTemplate derives bucket names from the bucket-name field of secrets, see
+	// BucketNameParams. Bucket names are used as they are if nil.
+	BucketNameTemplate *template.Template
+
+	// Encryption is the default encryption of buckets of secrets without an encryption field.
+	// Bucket encryption is left unmanaged if nil.
+	Encryption *backends.BucketEncryption
+
+	// Recorder records events on reconciled secrets. Events are discarded if nil.
+	Recorder events.EventRecorder
+
+	// RotationPeriod is applied to secrets without a rotation period annotation.
+	// Zero disables secret key rotation.
+	RotationPeriod time.Duration
+
+	// ResyncInterval re-verifies the backend resources of reconciled secrets periodically.
+	// Zero disables periodic resyncs.
+	ResyncInterval time.Duration
+
+	// reconciledVersions maps secret UIDs to the resource version of their last successful
+	// reconciliation, to tell resyncs apart from reconciliations of changed secrets
+	reconciledVersions sync.Map
 }
 
 // NewSecretReconciler creates a new reconciler instance
@@ -46,132 +99,413 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	// Fetch the Secret
 	var secret corev1.Secret
-	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+	if err := r.getSecret(ctx, req.NamespacedName, &secret); err != nil {
 		// Secret was deleted or doesn't exist
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Skip if not annotated
-	if !r.isAnnotated(&secret) {
+	state := r.annotationState(&secret)
+	previous := previousState(&secret)
+
+	// Secret left the scope, e.g. its label was removed: release it without touching its backend
+	// resources, even if it is being deleted
+	if !r.Scope.Includes(&secret) {
+		if previous == "" {
+			return ctrl.Result{}, nil
+		}
+		logger.Info("Releasing secret outside the watch scope", "namespace", secret.Namespace, "name", secret.Name)
+		return ctrl.Result{}, r.releaseSecret(ctx, &secret, previous)
+	}
+
+	// Secret is being deleted: clean up backend resources before releasing it. Paused secrets
+	// are released without touching them.
+	if !secret.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&secret, FinalizerName) {
+			return ctrl.Result{}, nil
+		}
+
+		if state == statePaused {
+			logger.Info("Releasing paused secret without cleaning up backend resources", "namespace", secret.Namespace, "name", secret.Name)
+		} else {
+			logger.Info("Finalizing secret", "namespace", secret.Namespace, "name", secret.Name)
+
+			if err := r.finalizeSecret(ctx, &secret); err != nil {
+				logger.Error(err, "Failed to finalize secret")
+				metrics.IncrementErrors()
+				newEventFunc(r.Recorder, &secret, actionFinalize)(corev1.EventTypeWarning, ReasonReconcileFailed, "%v", err)
+				return ctrl.Result{}, err
+			}
+		}
+
+		r.reconciledVersions.Delete(secret.UID)
+		metrics.DeleteCredentialsIssued(secret.Namespace, secret.Name)
+		controllerutil.RemoveFinalizer(&secret, FinalizerName)
+		if err := r.Update(ctx, &secret); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
 		return ctrl.Result{}, nil
 	}
 
+	switch state {
+	case stateDisabled:
+		// Skip if not enabled, releasing our finalizer and status if the secret was disabled
+		if previous == "" {
+			r.reconciledVersions.Delete(secret.UID)
+			metrics.DeleteCredentialsIssued(secret.Namespace, secret.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.releaseSecret(ctx, &secret, previous)
+
+	case statePaused:
+		// Leave paused secrets and their backend resources alone until they are enabled again
+		r.reconciledVersions.Delete(secret.UID)
+		if previous != statePaused {
+			if err := r.updateSecretStatus(ctx, &secret, SecretStatusPaused, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			r.recordStateTransition(ctx, &secret, previous, statePaused)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Ensure the finalizer is present so deletion can be handled
+	if controllerutil.AddFinalizer(&secret, FinalizerName) {
+		if err := r.Update(ctx, &secret); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	r.recordStateTransition(ctx, &secret, previous, stateEnabled)
+
 	logger.Info("Reconciling secret", "namespace", secret.Namespace, "name", secret.Name)
 
-	if err := r.handleSecret(ctx, &secret); err != nil {
+	status, err := r.handleSecret(ctx, &secret)
+	if err != nil {
 		logger.Error(err, "Failed to handle secret")
 		metrics.IncrementErrors()
+		if statusErr := r.updateSecretStatus(ctx, &secret, SecretStatusError, err); statusErr != nil {
+			logger.Error(statusErr, "Failed to update secret status")
+		}
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	if err := r.updateSecretStatus(ctx, &secret, status, nil); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if status != SecretStatusReady {
+		return ctrl.Result{}, nil
+	}
+
+	r.reconciledVersions.Store(secret.UID, secret.ResourceVersion)
+
+	result := ctrl.Result{RequeueAfter: r.ResyncInterval}
+	if untilRotation, ok := r.timeUntilRotation(ctx, &secret); ok && (result.RequeueAfter == 0 || untilRotation < result.RequeueAfter) {
+		result.RequeueAfter = untilRotation
+	}
+	return result, nil
+}
+
+// getSecret fetches a secret from the cache, falling back to the API server for secrets the
+// cache does not hold because they left the scope
+func (r *SecretReconciler) getSecret(ctx context.Context, key types.NamespacedName, secret *corev1.Secret) error {
+	err := r.Get(ctx, key, secret)
+	if !apierrors.IsNotFound(err) || r.APIReader == nil {
+		return err
+	}
+	return r.APIReader.Get(ctx, key, secret)
+}
+
+// reader returns the reader bypassing the cache, see APIReader
+func (r *SecretReconciler) reader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
 }
 
 // SetupWithManager sets up the controller with the Manager
 func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	// Only watch secrets with our annotation
+	// Only watch secrets in scope that are enabled or paused, or that we handled before. Secrets
+	// leaving the scope still carry our finalizer and are released.
 	pred := predicate.NewPredicateFuncs(func(obj client.Object) bool {
 		secret, ok := obj.(*corev1.Secret)
 		if !ok {
 			return false
 		}
-		return r.isAnnotated(secret)
+		if !r.Scope.Includes(secret) {
+			return controllerutil.ContainsFinalizer(secret, FinalizerName)
+		}
+		return r.annotationState(secret) != stateDisabled || previousState(secret) != ""
 	})
 
+	// Our own status annotation updates need no reconciliation
+	statusPred := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !onlyStatusChanged(e.ObjectOld, e.ObjectNew)
+		},
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Secret{}).
 		WithEventFilter(pred).
+		WithEventFilter(statusPred).
 		Complete(r)
 }
 
-func (r *SecretReconciler) handleSecret(ctx context.Context, secret *corev1.Secret) error {
+// handleSecret provisions the user and buckets of a secret on its backend. A failing bucket does
+// not stop the others; their errors are joined. It returns SecretStatusSkipped for secrets meant
+// for a backend this operator does not manage.
+func (r *SecretReconciler) handleSecret(ctx context.Context, secret *corev1.Secret) (SecretStatus, error) {
 	logger := log.FromContext(ctx)
+	recordEvent := newEventFunc(r.Recorder, secret, actionReconcile)
+	if r.isResync(secret) {
+		recordEvent = countDrift(ctx, recordEvent)
+	}
 	timer := metrics.StartTimer()
 	defer metrics.RecordHandleSecretDuration(timer)
 	metrics.IncrementSecretsProcessed()
 
-	data, err := r.decodeSecretData(secret)
+	data, err := decodeSecretData(secret)
 	if err != nil {
-		return err
+		return SecretStatusError, err
 	}
 
-	// Extract and validate required fields
-	bucketName := r.getField(data, "bucket-name", "BUCKET_NAME")
-	accessKey := r.getField(data, "access-key", "ACCESS_KEY", "ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
-	secretKey := r.getField(data, "secret-key", "SECRET_KEY", "SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
-	endpointURL := r.getField(data, "endpoint-url", "ENDPOINT_URL", "AWS_ENDPOINT_URL", "AWS_ENDPOINTS")
-
-	if bucketName == "" || accessKey == "" || secretKey == "" {
-		return fmt.Errorf("secret %s/%s is missing required fields (bucket-name, access-key, secret-key)",
-			secret.Namespace, secret.Name)
+	backend, err := r.backendFor(data)
+	if err != nil {
+		var routeErr *routingError
+		if !errors.As(err, &routeErr) {
+			return SecretStatusError, err
+		}
+		logger.Info("Skipping secret: "+routeErr.message,
+			"secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+		recordEvent(corev1.EventTypeWarning, routeErr.reason, "%s, skipping", routeErr.message)
+		return SecretStatusSkipped, nil
 	}
 
-	// Check endpoint URL if enforcement is enabled
-	if r.EnforceEndpoint && endpointURL != "" && endpointURL != r.Backend.GetEndpointURL() {
-		logger.Info("Skipping secret: endpoint URL mismatch",
-			"secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name),
-			"secretEndpoint", endpointURL,
-			"operatorEndpoint", r.Backend.GetEndpointURL())
-		return nil
+	if err := r.ensureCredentials(ctx, backend, secret, data, recordEvent); err != nil {
+		recordEvent(corev1.EventTypeWarning, ReasonReconcileFailed, "%v", err)
+		return SecretStatusError, err
 	}
 
-	// Get optional fields
-	userID := r.parseIntField(data, "user-id", "USER_ID")
-	groupID := r.parseIntField(data, "group-id", "GROUP_ID")
-	role := r.getFieldPtr(data, "role", "ROLE")
-
-	// Create or update user
-	userExists, err := r.Backend.UserExists(ctx, accessKey)
+	spec, err := r.parseSecret(secret, data)
 	if err != nil {
-		return fmt.Errorf("failed to check if user exists: %w", err)
+		reason := ReasonInvalidField
+		if errors.Is(err, errMissingFields) {
+			reason = ReasonMissingFields
+		}
+		recordEvent(corev1.EventTypeWarning, reason, "%v", err)
+		return SecretStatusError, fmt.Errorf("secret %s/%s: %w", secret.Namespace, secret.Name, err)
 	}
+	settings := spec.settings
 
-	if !userExists {
-		if err := r.Backend.CreateUser(ctx, accessKey, secretKey, role, userID, groupID); err != nil {
-			return fmt.Errorf("failed to create user: %w", err)
+	// Refuse buckets claimed by another secret before touching the backend. A conflict only
+	// fails its own bucket, the others are still provisioned.
+	var bucketErrs []error
+	claimed := make(map[string]bool, len(spec.bucketNames))
+	for _, bucketName := range spec.bucketNames {
+		ok, err := r.claims().claimBucket(ctx, backend, recordEvent, secret, bucketName, spec.accessKey, settings.shared)
+		if err == nil && !ok {
+			err = settings.checkShared(bucketName)
 		}
-		metrics.IncrementUsersCreated()
-	} else {
-		if err := r.Backend.UpdateUser(ctx, accessKey, &secretKey, userID, groupID); err != nil {
-			return fmt.Errorf("failed to update user: %w", err)
+		if err != nil {
+			recordEvent(corev1.EventTypeWarning, failureReason(err), "%v", err)
+			bucketErrs = append(bucketErrs, err)
+			continue
 		}
-		metrics.IncrementUsersUpdated()
+		claimed[bucketName] = ok
+	}
+	if len(claimed) == 0 {
+		return SecretStatusError, errors.Join(bucketErrs...)
 	}
 
-	// Create bucket if it doesn't exist
-	bucketExists, err := r.Backend.BucketExists(ctx, bucketName)
+	secretKey, err := r.rotateSecretKey(ctx, backend, secret, data, spec.accessKey, spec.secretKey, recordEvent)
 	if err != nil {
-		return fmt.Errorf("failed to check if bucket exists: %w", err)
+		recordEvent(corev1.EventTypeWarning, failureReason(err), "%v", err)
+		return SecretStatusError, err
 	}
 
-	if !bucketExists {
-		if err := r.Backend.CreateBucket(ctx, bucketName, &accessKey); err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
+	if err := ensureUser(ctx, backend, recordEvent, spec.accessKey, secretKey, spec.role, spec.userID, spec.groupID); err != nil {
+		recordEvent(corev1.EventTypeWarning, failureReason(err), "%v", err)
+		return SecretStatusError, err
+	}
+	metrics.SetCredentialsIssued(secret.Namespace, secret.Name, spec.accessKey, credentialsIssuedAt(secret))
+
+	for _, bucketName := range spec.bucketNames {
+		isClaimed, ok := claimed[bucketName]
+		if !ok {
+			continue
 		}
-		metrics.IncrementBucketsCreated()
-	} else {
-		// Check if owner needs to be changed
-		currentOwner, err := r.Backend.GetBucketOwner(ctx, bucketName)
-		if err == nil && currentOwner != accessKey {
-			if err := r.Backend.ChangeBucketOwner(ctx, bucketName, accessKey); err != nil {
-				return fmt.Errorf("failed to change bucket owner: %w", err)
-			}
-			metrics.IncrementBucketOwnersChanged()
+		if err := reconcileBucket(ctx, backend, recordEvent, bucketName, settings, isClaimed); err != nil {
+			err = fmt.Errorf("bucket %s: %w", bucketName, err)
+			recordEvent(corev1.EventTypeWarning, failureReason(err), "%v", err)
+			bucketErrs = append(bucketErrs, err)
 		}
 	}
 
-	return nil
+	// Keep the annotation until the CORS configuration was removed from every bucket
+	if settings.corsRules != nil || len(bucketErrs) == 0 {
+		if err := r.setCORSManaged(ctx, secret, settings.corsRules != nil); err != nil {
+			recordEvent(corev1.EventTypeWarning, ReasonReconcileFailed, "%v", err)
+			return SecretStatusError, err
+		}
+	}
+
+	if len(bucketErrs) > 0 {
+		return SecretStatusError, errors.Join(bucketErrs...)
+	}
+	return SecretStatusReady, nil
+}
+
+// errMissingFields is returned for secrets without a bucket name, access key or secret key
+var errMissingFields = errors.New("missing required fields (bucket-name, access-key, secret-key)")
+
+// secretSpec holds the validated fields of a secret
+type secretSpec struct {
+	accessKey   string
+	secretKey   string
+	bucketNames []string
+	role        *string
+	userID      *int
+	groupID     *int
+	settings    bucketSettings
+}
+
+// parseSecret extracts and validates the fields of a secret. It returns errMissingFields if a
+// required field is missing and the parse error of the first invalid field otherwise.
+func (r *SecretReconciler) parseSecret(secret *corev1.Secret, data map[string]string) (secretSpec, error) {
+	spec := secretSpec{
+		accessKey: getField(data, accessKeyFields...),
+		secretKey: getField(data, secretKeyFields...),
+		role:      getFieldPtr(data, "role", "ROLE"),
+	}
+
+	var err error
+	if spec.bucketNames, err = r.bucketNames(secret, data); err != nil {
+		return secretSpec{}, err
+	}
+	if len(spec.bucketNames) == 0 || spec.accessKey == "" || spec.secretKey == "" {
+		return secretSpec{}, errMissingFields
+	}
+
+	if spec.userID, err = parseIntField(data, "user-id", "USER_ID"); err != nil {
+		return secretSpec{}, err
+	}
+	if spec.groupID, err = parseIntField(data, "group-id", "GROUP_ID"); err != nil {
+		return secretSpec{}, err
+	}
+
+	settings := bucketSettings{
+		owner:      spec.accessKey,
+		policy:     getField(data, "bucket-policy", "BUCKET_POLICY"),
+		provenance: r.claims().provenanceTags(secret),
+	}
+	if settings.policyPreset, err = parseBucketPolicy(settings.policy); err != nil {
+		return secretSpec{}, err
+	}
+	settings.shared = grantsSharedAccess(settings.policyPreset)
+	if settings.versioning, err = parseBucketVersioning(data); err != nil {
+		return secretSpec{}, err
+	}
+	if settings.lifecycleRules, err = parseLifecycleRules(getField(data, lifecycleRulesFields...)); err != nil {
+		return secretSpec{}, err
+	}
+	if settings.quota, err = parseBucketQuota(data); err != nil {
+		return secretSpec{}, err
+	}
+	if settings.corsRules, err = parseCORSRules(getField(data, corsFields...)); err != nil {
+		return secretSpec{}, err
+	}
+	if settings.encryption, err = r.bucketEncryption(data); err != nil {
+		return secretSpec{}, err
+	}
+	settings.encryptionSet = getField(data, encryptionFields...) != "" || getField(data, kmsKeyIDFields...) != ""
+	if settings.tags, err = parseBucketTags(getField(data, bucketTagsFields...)); err != nil {
+		return secretSpec{}, err
+	}
+	_, settings.corsManaged = secret.Annotations[CORSManagedAnnotation]
+
+	spec.settings = settings
+	return spec, nil
+}
+
+// bucketSettings are the settings a secret applies to each of its buckets
+type bucketSettings struct {
+	owner string
+	// shared buckets keep their owner, see grantsSharedAccess
+	shared         bool
+	policy         string
+	policyPreset   backends.BucketPolicyPreset
+	versioning     bucketVersioning
+	lifecycleRules []backends.LifecycleRule
+	quota          *backends.BucketQuota
+	corsRules      []backends.CORSRule
+	corsManaged    bool
+	encryption     *backends.BucketEncryption
+	// encryptionSet is true if encryption comes from the secret rather than the operator default
+	encryptionSet bool
+	provenance    map[string]string
+	tags          map[string]string
 }
 
-func (r *SecretReconciler) isAnnotated(secret *corev1.Secret) bool {
-	if secret.Annotations == nil {
-		return false
+// reconcileBucket provisions a bucket and applies the settings to it. Only the secret holding
+// the claim of a bucket (claimed) manages its settings; a secret sharing a bucket claimed by
+// another secret only adds its own policy statement.
+func reconcileBucket(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, settings bucketSettings, claimed bool) error {
+	created, err := ensureBucket(ctx, backend, recordEvent, bucketName, settings.owner, !settings.shared, settings.versioning.objectLock)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		if err := settings.checkShared(bucketName); err != nil {
+			return err
+		}
+		return reconcileBucketPolicy(ctx, backend, recordEvent, bucketName, settings.owner, settings.policy, settings.policyPreset)
+	}
+
+	if err := reconcileBucketTags(ctx, backend, recordEvent, bucketName, settings.provenance, settings.tags, created); err != nil {
+		return err
+	}
+
+	if err := reconcileBucketEncryption(ctx, backend, recordEvent, bucketName, settings.encryption); err != nil {
+		return err
 	}
-	_, exists := secret.Annotations[r.AnnotationKey]
-	return exists
+
+	if err := reconcileBucketVersioning(ctx, backend, recordEvent, bucketName, settings.versioning); err != nil {
+		return err
+	}
+
+	if err := reconcileBucketLifecycle(ctx, backend, recordEvent, bucketName, settings.lifecycleRules); err != nil {
+		return err
+	}
+
+	if err := reconcileBucketQuota(ctx, backend, recordEvent, bucketName, settings.quota); err != nil {
+		return err
+	}
+
+	if err := reconcileBucketCors(ctx, backend, recordEvent, bucketName, settings.corsRules, settings.corsManaged); err != nil {
+		return err
+	}
+
+	return reconcileBucketPolicy(ctx, backend, recordEvent, bucketName, settings.owner, settings.policy, settings.policyPreset)
+}
+
+// isResync reports whether the secret is unchanged since its last successful reconciliation
+func (r *SecretReconciler) isResync(secret *corev1.Secret) bool {
+	version, ok := r.reconciledVersions.Load(secret.UID)
+	return ok && version == secret.ResourceVersion
 }
 
-func (r *SecretReconciler) decodeSecretData(secret *corev1.Secret) (map[string]string, error) {
+// Field names accepted for each secret value, in order of preference
+var (
+	bucketNameFields  = []string{"bucket-name", "BUCKET_NAME"}
+	accessKeyFields   = []string{"access-key", "ACCESS_KEY", "ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"}
+	secretKeyFields   = []string{"secret-key", "SECRET_KEY", "SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"}
+	endpointURLFields = []string{"endpoint-url", "ENDPOINT_URL", "AWS_ENDPOINT_URL", "AWS_ENDPOINTS"}
+)
+
+func decodeSecretData(secret *corev1.Secret) (map[string]string, error) {
 	decoded := make(map[string]string)
 	for key, value := range secret.Data {
 		decoded[key] = string(value)
@@ -185,7 +519,7 @@ func (r *SecretReconciler) decodeSecretData(secret *corev1.Secret) (map[string]s
 	return decoded, nil
 }
 
-func (r *SecretReconciler) getField(data map[string]string, keys ...string) string {
+func getField(data map[string]string, keys ...string) string {
 	for _, key := range keys {
 		if val, ok := data[key]; ok && val != "" {
 			return val
@@ -194,22 +528,23 @@ func (r *SecretReconciler) getField(data map[string]string, keys ...string) stri
 	return ""
 }
 
-func (r *SecretReconciler) getFieldPtr(data map[string]string, keys ...string) *string {
-	val := r.getField(data, keys...)
+func getFieldPtr(data map[string]string, keys ...string) *string {
+	val := getField(data, keys...)
 	if val == "" {
 		return nil
 	}
 	return &val
 }
 
-func (r *SecretReconciler) parseIntField(data map[string]string, keys ...string) *int {
-	val := r.getField(data, keys...)
+// parseIntField parses an optional integer field. It returns nil if the field is not set.
+func parseIntField(data map[string]string, keys ...string) (*int, error) {
+	val := getField(data, keys...)
 	if val == "" {
-		return nil
+		return nil, nil
 	}
-	var i int
-	if _, err := fmt.Sscanf(val, "%d", &i); err == nil {
-		return &i
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer, got %q", keys[0], val)
 	}
-	return nil
+	return &i, nil
 }
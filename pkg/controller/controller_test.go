@@ -2,22 +2,35 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
 	"github.com/runningman84/s3-resource-operator/pkg/backends"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func newTestScheme() *runtime.Scheme {
 	scheme := runtime.NewScheme()
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = s3v1alpha1.AddToScheme(scheme)
 	return scheme
 }
 
@@ -60,6 +73,39 @@ func TestIsAnnotated_Runtime(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "disabled",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "false",
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "paused",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "paused",
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "empty value",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "",
+					},
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,7 +144,7 @@ func TestHandleSecret_CreateNewUserAndBucket_Runtime(t *testing.T) {
 		EnforceEndpoint: false,
 	}
 
-	err := r.handleSecret(context.Background(), secret)
+	_, err := r.handleSecret(context.Background(), secret)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -163,7 +209,7 @@ func TestHandleSecret_MissingRequiredFields_Runtime(t *testing.T) {
 				AnnotationKey: "test-annotation",
 			}
 
-			err := r.handleSecret(context.Background(), tt.secret)
+			_, err := r.handleSecret(context.Background(), tt.secret)
 			if err == nil {
 				t.Fatal("expected error, got nil")
 			}
@@ -234,7 +280,7 @@ func TestHandleSecret_EndpointEnforcement_Runtime(t *testing.T) {
 				EnforceEndpoint: tt.enforceEndpoint,
 			}
 
-			err := r.handleSecret(context.Background(), secret)
+			_, err := r.handleSecret(context.Background(), secret)
 			if tt.expectErr && err == nil {
 				t.Fatal("expected error, got nil")
 			}
@@ -475,3 +521,2949 @@ func TestReconcile_MissingRequiredFields(t *testing.T) {
 		t.Error("did not expect requeue")
 	}
 }
+
+func TestReconcile_AddsFinalizer(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"s3-resource-operator.io/enabled": "true",
+			},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "s3-resource-operator.io/enabled",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(&updated, FinalizerName) {
+		t.Errorf("expected finalizer %s to be added, got %v", FinalizerName, updated.Finalizers)
+	}
+}
+
+func TestAnnotationState(t *testing.T) {
+	r := &SecretReconciler{AnnotationKey: "s3-resource-operator.io/enabled"}
+
+	tests := []struct {
+		value    string
+		expected secretState
+	}{
+		{"true", stateEnabled},
+		{"True", stateEnabled},
+		{"1", stateEnabled},
+		{"false", stateDisabled},
+		{"0", stateDisabled},
+		{"", stateDisabled},
+		{"yes", stateDisabled},
+		{"paused", statePaused},
+		{"Paused", statePaused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": tt.value},
+			}}
+			if got := r.annotationState(secret); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReconcile_PauseResumeDisable(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "default",
+			UID:       "test-uid",
+			Annotations: map[string]string{
+				"s3-resource-operator.io/enabled": "true",
+				DeletionPolicyAnnotation:          string(DeletionPolicyDeleteAll),
+			},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	recorder := events.NewFakeRecorder(20)
+
+	r := &SecretReconciler{
+		Client:         client,
+		Scheme:         scheme,
+		Backend:        mockBackend,
+		AnnotationKey:  "s3-resource-operator.io/enabled",
+		Recorder:       recorder,
+		ResyncInterval: 5 * time.Minute,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	drainEvents(recorder)
+
+	setState := func(value string) *corev1.Secret {
+		t.Helper()
+		var updated corev1.Secret
+		if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+			t.Fatalf("failed to get secret: %v", err)
+		}
+		updated.Annotations["s3-resource-operator.io/enabled"] = value
+		if err := client.Update(context.Background(), &updated); err != nil {
+			t.Fatalf("failed to update secret: %v", err)
+		}
+		result, err := r.Reconcile(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if value != "true" && result.RequeueAfter != 0 {
+			t.Errorf("expected no requeue for %q, got %v", value, result.RequeueAfter)
+		}
+		if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+			t.Fatalf("failed to get secret: %v", err)
+		}
+		return &updated
+	}
+
+	// Paused secrets are left alone: drift is not corrected
+	delete(mockBackend.Buckets, "test-bucket")
+	updated := setState("paused")
+	if _, ok := mockBackend.Buckets["test-bucket"]; ok {
+		t.Error("expected paused secret not to recreate its bucket")
+	}
+	if got := updated.Annotations[StatusAnnotation]; got != string(SecretStatusPaused) {
+		t.Errorf("expected status %s, got %q", SecretStatusPaused, got)
+	}
+	if !controllerutil.ContainsFinalizer(updated, FinalizerName) {
+		t.Error("expected paused secret to keep its finalizer")
+	}
+	if got := drainEvents(recorder); len(got) != 1 || !strings.HasPrefix(got[0], "Normal Paused") {
+		t.Errorf("expected a Paused event, got %v", got)
+	}
+
+	// Reconciling a paused secret again records no further transition
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := drainEvents(recorder); len(got) != 0 {
+		t.Errorf("expected no events, got %v", got)
+	}
+
+	// Resuming reconciles the secret again
+	updated = setState("true")
+	if _, ok := mockBackend.Buckets["test-bucket"]; !ok {
+		t.Error("expected resumed secret to recreate its bucket")
+	}
+	if got := updated.Annotations[StatusAnnotation]; got != string(SecretStatusReady) {
+		t.Errorf("expected status %s, got %q", SecretStatusReady, got)
+	}
+	if got := drainEvents(recorder); len(got) == 0 || !strings.HasPrefix(got[0], "Normal Resumed") {
+		t.Errorf("expected a Resumed event first, got %v", got)
+	}
+
+	// Disabling releases the secret without deleting anything
+	updated = setState("false")
+	if controllerutil.ContainsFinalizer(updated, FinalizerName) {
+		t.Error("expected finalizer to be removed")
+	}
+	if _, ok := updated.Annotations[StatusAnnotation]; ok {
+		t.Error("expected status annotation to be removed")
+	}
+	if _, ok := mockBackend.Buckets["test-bucket"]; !ok {
+		t.Error("expected disabled secret to keep its bucket")
+	}
+	if mockBackend.DeleteUserCalls != 0 {
+		t.Errorf("expected 0 DeleteUser calls, got %d", mockBackend.DeleteUserCalls)
+	}
+	if got := drainEvents(recorder); len(got) != 1 || !strings.HasPrefix(got[0], "Normal Disabled") {
+		t.Errorf("expected a Disabled event, got %v", got)
+	}
+}
+
+func TestReconcile_DeletePausedSecret(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key"}
+	mockBackend.Buckets["test-bucket"] = "test-key"
+	scheme := newTestScheme()
+
+	now := metav1.Now()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-secret",
+			Namespace:         "default",
+			Finalizers:        []string{FinalizerName},
+			DeletionTimestamp: &now,
+			Annotations: map[string]string{
+				"s3-resource-operator.io/enabled": "paused",
+				DeletionPolicyAnnotation:          string(DeletionPolicyDeleteAll),
+			},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "s3-resource-operator.io/enabled",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockBackend.DeleteUserCalls != 0 {
+		t.Errorf("expected 0 DeleteUser calls, got %d", mockBackend.DeleteUserCalls)
+	}
+	if _, ok := mockBackend.Buckets["test-bucket"]; !ok {
+		t.Error("expected bucket of paused secret to be retained")
+	}
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err == nil && controllerutil.ContainsFinalizer(&updated, FinalizerName) {
+		t.Error("expected finalizer to be removed")
+	}
+}
+
+func TestReconcile_RemovesFinalizerWhenAnnotationRemoved(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key"}
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-secret",
+			Namespace:  "default",
+			Finalizers: []string{FinalizerName},
+			Annotations: map[string]string{
+				DeletionPolicyAnnotation: string(DeletionPolicyDeleteAll),
+			},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "s3-resource-operator.io/enabled",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if controllerutil.ContainsFinalizer(&updated, FinalizerName) {
+		t.Error("expected finalizer to be removed")
+	}
+	if mockBackend.DeleteUserCalls != 0 {
+		t.Errorf("expected 0 DeleteUser calls, got %d", mockBackend.DeleteUserCalls)
+	}
+}
+
+func TestReconcile_ReleasesSecretLeavingScope(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name     string
+		deleting bool
+	}{
+		{name: "label removed"},
+		{name: "label removed while deleting", deleting: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key"}
+			mockBackend.Buckets["test-bucket"] = "test-key"
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-secret",
+					Namespace:  "default",
+					Finalizers: []string{FinalizerName},
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "true",
+						DeletionPolicyAnnotation:          string(DeletionPolicyDeleteAll),
+						StatusAnnotation:                  string(SecretStatusReady),
+					},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			if tt.deleting {
+				secret.DeletionTimestamp = &now
+			}
+
+			apiClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			// The label-selector cache no longer holds the secret
+			cachedClient := interceptor.NewClient(apiClient, interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					return apierrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+				},
+			})
+			selector, err := labels.Parse("s3-resource-operator.io/managed=true")
+			if err != nil {
+				t.Fatalf("failed to parse selector: %v", err)
+			}
+
+			r := &SecretReconciler{
+				Client:        cachedClient,
+				APIReader:     apiClient,
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "s3-resource-operator.io/enabled",
+				Scope:         WatchScope{Selector: selector},
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated corev1.Secret
+			err = apiClient.Get(context.Background(), req.NamespacedName, &updated)
+			switch {
+			case tt.deleting && !apierrors.IsNotFound(err):
+				t.Errorf("expected secret to be deleted, got %v", err)
+			case !tt.deleting && err != nil:
+				t.Fatalf("failed to get secret: %v", err)
+			case !tt.deleting && controllerutil.ContainsFinalizer(&updated, FinalizerName):
+				t.Error("expected finalizer to be removed")
+			case !tt.deleting && updated.Annotations[StatusAnnotation] != "":
+				t.Errorf("expected status to be removed, got %q", updated.Annotations[StatusAnnotation])
+			}
+			if mockBackend.DeleteUserCalls != 0 {
+				t.Errorf("expected 0 DeleteUser calls, got %d", mockBackend.DeleteUserCalls)
+			}
+			if _, ok := mockBackend.Buckets["test-bucket"]; !ok {
+				t.Error("expected bucket of secret outside the scope to be retained")
+			}
+		})
+	}
+}
+
+func TestReconcile_DeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultPolicy DeletionPolicy
+		annotation    string
+		nonEmpty      bool
+		bucketOwner   string
+		origin        string
+		claimedBy     string
+		expectUser    bool
+		expectBucket  bool
+		expectEmptied bool
+	}{
+		{
+			name:         "default retains everything",
+			expectUser:   true,
+			expectBucket: true,
+		},
+		{
+			name:         "delete-user keeps bucket",
+			annotation:   "delete-user",
+			expectUser:   false,
+			expectBucket: true,
+		},
+		{
+			name:         "delete-user-and-empty-bucket deletes empty bucket",
+			annotation:   "delete-user-and-empty-bucket",
+			expectUser:   false,
+			expectBucket: false,
+		},
+		{
+			name:         "delete-user-and-empty-bucket keeps non-empty bucket",
+			annotation:   "delete-user-and-empty-bucket",
+			nonEmpty:     true,
+			expectUser:   false,
+			expectBucket: true,
+		},
+		{
+			name:          "delete-all purges non-empty bucket",
+			annotation:    "delete-all",
+			nonEmpty:      true,
+			expectUser:    false,
+			expectBucket:  false,
+			expectEmptied: true,
+		},
+		{
+			name:          "operator default applies without annotation",
+			defaultPolicy: DeletionPolicyDeleteAll,
+			expectUser:    false,
+			expectBucket:  false,
+			expectEmptied: true,
+		},
+		{
+			name:          "annotation overrides operator default",
+			defaultPolicy: DeletionPolicyDeleteAll,
+			annotation:    "retain",
+			expectUser:    true,
+			expectBucket:  true,
+		},
+		{
+			name:         "invalid annotation retains everything",
+			annotation:   "delete-everything",
+			expectUser:   true,
+			expectBucket: true,
+		},
+		{
+			name:         "bucket owned by another user is kept",
+			annotation:   "delete-all",
+			bucketOwner:  "other-key",
+			expectUser:   false,
+			expectBucket: true,
+		},
+		{
+			name:         "adopted bucket is kept",
+			annotation:   "delete-all",
+			origin:       BucketOriginAdopted,
+			expectUser:   false,
+			expectBucket: true,
+		},
+		{
+			name:          "created bucket is deleted",
+			annotation:    "delete-all",
+			origin:        BucketOriginCreated,
+			expectUser:    false,
+			expectBucket:  false,
+			expectEmptied: true,
+		},
+		{
+			name:         "bucket claimed by another secret is kept",
+			annotation:   "delete-all",
+			origin:       BucketOriginCreated,
+			claimedBy:    "5678",
+			expectUser:   false,
+			expectBucket: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			scheme := newTestScheme()
+
+			owner := "test-key"
+			if tt.bucketOwner != "" {
+				owner = tt.bucketOwner
+			}
+			mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key", SecretKey: "test-secret"}
+			mockBackend.Buckets["test-bucket"] = owner
+			if tt.nonEmpty {
+				mockBackend.NonEmptyBuckets["test-bucket"] = true
+			}
+			if tt.origin != "" {
+				mockBackend.Tags["test-bucket"] = map[string]string{TagOrigin: tt.origin}
+			}
+			if tt.claimedBy != "" {
+				mockBackend.Tags["test-bucket"][TagSecretUID] = tt.claimedBy
+			}
+
+			now := metav1.Now()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-secret",
+					Namespace:         "default",
+					UID:               "1234",
+					Finalizers:        []string{FinalizerName},
+					DeletionTimestamp: &now,
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "true",
+					},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			if tt.annotation != "" {
+				secret.Annotations[DeletionPolicyAnnotation] = tt.annotation
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+			r := &SecretReconciler{
+				Client:         client,
+				Scheme:         scheme,
+				Backend:        mockBackend,
+				AnnotationKey:  "s3-resource-operator.io/enabled",
+				DeletionPolicy: tt.defaultPolicy,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, exists := mockBackend.Users["test-key"]; exists != tt.expectUser {
+				t.Errorf("expected user exists=%v, got %v", tt.expectUser, exists)
+			}
+			if _, exists := mockBackend.Buckets["test-bucket"]; exists != tt.expectBucket {
+				t.Errorf("expected bucket exists=%v, got %v", tt.expectBucket, exists)
+			}
+			if emptied := mockBackend.EmptyBucketCalls > 0; emptied != tt.expectEmptied {
+				t.Errorf("expected bucket emptied=%v, got %v", tt.expectEmptied, emptied)
+			}
+
+			// The secret is gone once the finalizer has been removed
+			var updated corev1.Secret
+			if err := client.Get(context.Background(), req.NamespacedName, &updated); !apierrors.IsNotFound(err) {
+				t.Errorf("expected secret to be deleted, got err=%v finalizers=%v", err, updated.Finalizers)
+			}
+		})
+	}
+}
+
+func TestReconcile_DeletionErrorKeepsFinalizer(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key"}
+	mockBackend.DeleteUserError = fmt.Errorf("backend unavailable")
+	scheme := newTestScheme()
+
+	now := metav1.Now()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-secret",
+			Namespace:         "default",
+			Finalizers:        []string{FinalizerName},
+			DeletionTimestamp: &now,
+			Annotations: map[string]string{
+				"s3-resource-operator.io/enabled": "true",
+				DeletionPolicyAnnotation:          "delete-user",
+			},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "s3-resource-operator.io/enabled",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(&updated, FinalizerName) {
+		t.Error("expected finalizer to be kept after failed cleanup")
+	}
+}
+
+func TestParseDeletionPolicy(t *testing.T) {
+	for _, valid := range []string{"retain", "delete-user", "delete-user-and-empty-bucket", "delete-all"} {
+		if _, err := ParseDeletionPolicy(valid); err != nil {
+			t.Errorf("unexpected error for %q: %v", valid, err)
+		}
+	}
+	if _, err := ParseDeletionPolicy("delete"); err == nil {
+		t.Error("expected error for invalid policy")
+	}
+}
+
+func TestHandleSecret_BucketPolicy(t *testing.T) {
+	scheme := newTestScheme()
+
+	newSecret := func(policy string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reader-secret",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"bucket-name": []byte("shared-bucket"),
+				"access-key":  []byte("reader-key"),
+				"secret-key":  []byte("reader-secret"),
+			},
+		}
+		if policy != "" {
+			secret.Data["bucket-policy"] = []byte(policy)
+		}
+		return secret
+	}
+
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.Buckets["shared-bucket"] = "owner-key"
+
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+	}
+
+	// A read-only grant attaches a policy but leaves ownership alone
+	if _, err := r.handleSecret(context.Background(), newSecret("read-only")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockBackend.Buckets["shared-bucket"] != "owner-key" {
+		t.Errorf("expected owner to stay 'owner-key', got %q", mockBackend.Buckets["shared-bucket"])
+	}
+	if mockBackend.ChangeBucketOwnerCalls != 0 {
+		t.Errorf("expected 0 ChangeBucketOwner calls, got %d", mockBackend.ChangeBucketOwnerCalls)
+	}
+	if !backends.HasBucketPolicyStatement(mockBackend.Policies["shared-bucket"], "reader-key") {
+		t.Errorf("expected policy statement for reader-key, got %q", mockBackend.Policies["shared-bucket"])
+	}
+
+	// Reconciling again does not rewrite an unchanged policy
+	if _, err := r.handleSecret(context.Background(), newSecret("read-only")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockBackend.PutBucketPolicyCalls != 1 {
+		t.Errorf("expected 1 PutBucketPolicy call, got %d", mockBackend.PutBucketPolicyCalls)
+	}
+
+	// Removing the field removes the generated statement
+	if _, err := r.handleSecret(context.Background(), newSecret("")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exists := mockBackend.Policies["shared-bucket"]; exists {
+		t.Errorf("expected policy to be deleted, got %q", mockBackend.Policies["shared-bucket"])
+	}
+
+	// A raw JSON policy replaces the document as-is
+	raw := `{"Version":"2012-10-17","Statement":[]}`
+	if _, err := r.handleSecret(context.Background(), newSecret(raw)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockBackend.Policies["shared-bucket"] != raw {
+		t.Errorf("expected raw policy, got %q", mockBackend.Policies["shared-bucket"])
+	}
+}
+
+func TestHandleSecret_InvalidBucketPolicy(t *testing.T) {
+	scheme := newTestScheme()
+
+	for _, policy := range []string{"write-only", "{not json"} {
+		t.Run(policy, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+				Data: map[string][]byte{
+					"bucket-name":   []byte("test-bucket"),
+					"access-key":    []byte("test-key"),
+					"secret-key":    []byte("test-secret"),
+					"bucket-policy": []byte(policy),
+				},
+			}
+
+			r := &SecretReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).Build(),
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "test-annotation",
+			}
+
+			if _, err := r.handleSecret(context.Background(), secret); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if mockBackend.CreateUserCalls != 0 {
+				t.Errorf("expected no backend changes, got %d CreateUser calls", mockBackend.CreateUserCalls)
+			}
+		})
+	}
+}
+
+func TestReconcile_StatusAnnotationsAndEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		createErr    error
+		expectErr    bool
+		expectStatus SecretStatus
+		expectEvents []string
+	}{
+		{
+			name: "success",
+			data: map[string][]byte{
+				"bucket-name": []byte("test-bucket"),
+				"access-key":  []byte("test-key"),
+				"secret-key":  []byte("test-secret"),
+			},
+			expectStatus: SecretStatusReady,
+			expectEvents: []string{"Normal UserCreated", "Normal BucketCreated"},
+		},
+		{
+			name: "missing fields",
+			data: map[string][]byte{
+				"bucket-name": []byte("test-bucket"),
+			},
+			expectErr:    true,
+			expectStatus: SecretStatusError,
+			expectEvents: []string{"Warning MissingFields"},
+		},
+		{
+			name: "endpoint mismatch",
+			data: map[string][]byte{
+				"bucket-name":  []byte("test-bucket"),
+				"access-key":   []byte("test-key"),
+				"secret-key":   []byte("test-secret"),
+				"endpoint-url": []byte("http://other-server:9000"),
+			},
+			expectStatus: SecretStatusSkipped,
+			expectEvents: []string{"Warning EndpointMismatch"},
+		},
+		{
+			name: "backend error",
+			data: map[string][]byte{
+				"bucket-name": []byte("test-bucket"),
+				"access-key":  []byte("test-key"),
+				"secret-key":  []byte("test-secret"),
+			},
+			createErr:    fmt.Errorf("backend unavailable"),
+			expectErr:    true,
+			expectStatus: SecretStatusError,
+			expectEvents: []string{"Warning ReconcileFailed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.CreateUserError = tt.createErr
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-secret",
+					Namespace:   "default",
+					Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+				},
+				Data: tt.data,
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			recorder := events.NewFakeRecorder(10)
+
+			r := &SecretReconciler{
+				Client:          client,
+				Scheme:          scheme,
+				Backend:         mockBackend,
+				AnnotationKey:   "s3-resource-operator.io/enabled",
+				EnforceEndpoint: true,
+				Recorder:        recorder,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+			_, err := r.Reconcile(context.Background(), req)
+			if tt.expectErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated corev1.Secret
+			if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			if status := updated.Annotations[StatusAnnotation]; status != string(tt.expectStatus) {
+				t.Errorf("expected status %q, got %q", tt.expectStatus, status)
+			}
+			if updated.Annotations[LastReconciledAnnotation] == "" {
+				t.Error("expected last-reconciled annotation to be set")
+			}
+			if lastErr := updated.Annotations[LastErrorAnnotation]; (lastErr != "") != tt.expectErr {
+				t.Errorf("unexpected last-error annotation %q", lastErr)
+			}
+
+			close(recorder.Events)
+			var got []string
+			for e := range recorder.Events {
+				got = append(got, e)
+			}
+			if len(got) != len(tt.expectEvents) {
+				t.Fatalf("expected events %v, got %v", tt.expectEvents, got)
+			}
+			for i, prefix := range tt.expectEvents {
+				if !strings.HasPrefix(got[i], prefix) {
+					t.Errorf("expected event %q to start with %q", got[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestOnlyStatusChanged(t *testing.T) {
+	base := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+		},
+		Data: map[string][]byte{"bucket-name": []byte("test-bucket")},
+	}
+
+	statusOnly := base.DeepCopy()
+	statusOnly.Annotations[StatusAnnotation] = string(SecretStatusReady)
+	statusOnly.Annotations[LastReconciledAnnotation] = "2024-01-01T00:00:00Z"
+	if !onlyStatusChanged(base, statusOnly) {
+		t.Error("expected status-only update to be detected")
+	}
+
+	dataChanged := statusOnly.DeepCopy()
+	dataChanged.Data["bucket-name"] = []byte("other-bucket")
+	if onlyStatusChanged(statusOnly, dataChanged) {
+		t.Error("expected data change to trigger reconciliation")
+	}
+
+	annotationChanged := statusOnly.DeepCopy()
+	annotationChanged.Annotations[DeletionPolicyAnnotation] = string(DeletionPolicyDeleteAll)
+	if onlyStatusChanged(statusOnly, annotationChanged) {
+		t.Error("expected annotation change to trigger reconciliation")
+	}
+}
+
+func TestReconcile_ResyncCorrectsDrift(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "default",
+			UID:         "test-uid",
+			Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+		},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	recorder := events.NewFakeRecorder(20)
+
+	r := &SecretReconciler{
+		Client:         client,
+		Scheme:         scheme,
+		Backend:        mockBackend,
+		AnnotationKey:  "s3-resource-operator.io/enabled",
+		Recorder:       recorder,
+		ResyncInterval: 5 * time.Minute,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	result, err := r.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != 5*time.Minute {
+		t.Errorf("expected requeue after 5m, got %v", result.RequeueAfter)
+	}
+	drainEvents(recorder)
+
+	// Drift on the backend: the secret key was changed and the bucket was given away
+	mockBackend.Users["test-key"].SecretKey = "changed"
+	mockBackend.Buckets["test-bucket"] = "someone-else"
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockBackend.Users["test-key"].SecretKey != "test-secret" {
+		t.Error("expected secret key to be reset")
+	}
+	if mockBackend.Buckets["test-bucket"] != "test-key" {
+		t.Error("expected bucket owner to be restored")
+	}
+
+	got := drainEvents(recorder)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %v", got)
+	}
+	for _, e := range got {
+		if !strings.HasPrefix(e, "Warning DriftCorrected") {
+			t.Errorf("expected DriftCorrected event, got %q", e)
+		}
+	}
+
+	// Changes caused by editing the secret are not drift
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	updated.Data["bucket-name"] = []byte("other-bucket")
+	if err := client.Update(context.Background(), &updated); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got = drainEvents(recorder)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "Normal BucketCreated") {
+		t.Errorf("expected a BucketCreated event, got %v", got)
+	}
+}
+
+func drainEvents(recorder *events.FakeRecorder) []string {
+	var got []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+func TestReconcile_GenerateCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		annotation      string
+		data            map[string][]byte
+		expectErr       bool
+		expectAccessKey string
+	}{
+		{
+			name:       "generates both keys",
+			annotation: "true",
+			data:       map[string][]byte{"bucket-name": []byte("test-bucket")},
+		},
+		{
+			name:       "keeps existing access key",
+			annotation: "true",
+			data: map[string][]byte{
+				"bucket-name": []byte("test-bucket"),
+				"access-key":  []byte("existing-key"),
+			},
+			expectAccessKey: "existing-key",
+		},
+		{
+			name:       "disabled",
+			annotation: "false",
+			data:       map[string][]byte{"bucket-name": []byte("test-bucket")},
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "true",
+						GenerateCredentialsAnnotation:     tt.annotation,
+					},
+				},
+				Data: tt.data,
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+			r := &SecretReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "s3-resource-operator.io/enabled",
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+			_, err := r.Reconcile(context.Background(), req)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated corev1.Secret
+			if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			accessKey := string(updated.Data["access-key"])
+			secretKey := string(updated.Data["secret-key"])
+			if tt.expectAccessKey != "" && accessKey != tt.expectAccessKey {
+				t.Errorf("expected access key %q, got %q", tt.expectAccessKey, accessKey)
+			}
+			if accessKey == "" || len(secretKey) < 20 {
+				t.Fatalf("expected generated credentials, got access key %q and secret key %q", accessKey, secretKey)
+			}
+
+			user, exists := mockBackend.Users[accessKey]
+			if !exists {
+				t.Fatal("expected user to be created with the generated access key")
+			}
+			if user.SecretKey != secretKey {
+				t.Error("expected user to be created with the generated secret key")
+			}
+		})
+	}
+}
+
+func TestReconcile_RotateSecretKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		period       string
+		lastRotated  time.Time
+		updateErr    error
+		expectRotate bool
+	}{
+		{name: "rotation due", period: "90d", lastRotated: time.Now().Add(-100 * 24 * time.Hour), expectRotate: true},
+		{name: "backend rejects the new key", period: "90d", lastRotated: time.Now().Add(-100 * 24 * time.Hour), updateErr: errors.New("update failed")},
+		{name: "rotation not due", period: "90d", lastRotated: time.Now().Add(-10 * 24 * time.Hour)},
+		{name: "rotation disabled", period: "0", lastRotated: time.Now().Add(-100 * 24 * time.Hour)},
+		{name: "invalid period", period: "soon", lastRotated: time.Now().Add(-100 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key", SecretKey: "test-secret"}
+			mockBackend.Buckets["test-bucket"] = "test-key"
+			mockBackend.UpdateUserError = tt.updateErr
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "true",
+						RotationPeriodAnnotation:          tt.period,
+						LastRotatedAnnotation:             tt.lastRotated.UTC().Format(time.RFC3339),
+					},
+				},
+				Data: map[string][]byte{
+					"bucket-name":           []byte("test-bucket"),
+					"access-key":            []byte("test-key"),
+					"AWS_SECRET_ACCESS_KEY": []byte("test-secret"),
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+			r := &SecretReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "s3-resource-operator.io/enabled",
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+			result, err := r.Reconcile(context.Background(), req)
+			if (err != nil) != (tt.updateErr != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated corev1.Secret
+			if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			newSecretKey := string(updated.Data["AWS_SECRET_ACCESS_KEY"])
+
+			if !tt.expectRotate {
+				if newSecretKey != "test-secret" || mockBackend.Users["test-key"].SecretKey != "test-secret" {
+					t.Error("did not expect the secret key to be rotated")
+				}
+				if updated.Annotations[LastRotatedAnnotation] != secret.Annotations[LastRotatedAnnotation] {
+					t.Errorf("did not expect the rotation timestamp to change, got %s", updated.Annotations[LastRotatedAnnotation])
+				}
+				return
+			}
+
+			if newSecretKey == "test-secret" {
+				t.Fatal("expected the secret key in the secret to be rotated")
+			}
+			if _, exists := updated.Data["secret-key"]; exists {
+				t.Error("expected the rotated key to be written to the existing field")
+			}
+			if mockBackend.Users["test-key"].SecretKey != newSecretKey {
+				t.Error("expected the backend user to use the rotated secret key")
+			}
+			if lastRotated := credentialsIssuedAt(&updated); time.Since(lastRotated) > time.Minute {
+				t.Errorf("expected rotation timestamp to be updated, got %v", lastRotated)
+			}
+			if result.RequeueAfter < 89*24*time.Hour {
+				t.Errorf("expected requeue at the next rotation, got %v", result.RequeueAfter)
+			}
+		})
+	}
+}
+
+func TestParseRotationPeriod(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90d", want: 90 * 24 * time.Hour},
+		{value: "2160h", want: 2160 * time.Hour},
+		{value: "0", want: 0},
+		{value: "-1d", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRotationPeriod(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRotationPeriod(%q) error = %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseRotationPeriod(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestHandleSecret_BackendRouting(t *testing.T) {
+	tests := []struct {
+		name         string
+		fields       map[string]string
+		expectStatus SecretStatus
+		expectCalled string
+	}{
+		{
+			name:         "default backend",
+			expectStatus: SecretStatusReady,
+			expectCalled: "default",
+		},
+		{
+			name:         "by backend name",
+			fields:       map[string]string{"backend": "other"},
+			expectStatus: SecretStatusReady,
+			expectCalled: "other",
+		},
+		{
+			name:         "by endpoint URL",
+			fields:       map[string]string{"endpoint-url": "http://other:9000/"},
+			expectStatus: SecretStatusReady,
+			expectCalled: "other",
+		},
+		{
+			name:         "unknown backend name",
+			fields:       map[string]string{"backend": "unknown"},
+			expectStatus: SecretStatusSkipped,
+		},
+		{
+			name:         "unknown endpoint URL",
+			fields:       map[string]string{"endpoint-url": "http://unknown:9000"},
+			expectStatus: SecretStatusSkipped,
+		},
+		{
+			name:         "backend name with mismatched endpoint URL",
+			fields:       map[string]string{"backend": "other", "endpoint-url": "http://localhost:9000"},
+			expectStatus: SecretStatusSkipped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultBackend := backends.NewMockBackend("http://localhost:9000")
+			otherBackend := backends.NewMockBackend("http://other:9000")
+			registry := backends.NewRegistry()
+			if err := registry.Register("default", defaultBackend, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := registry.Register("other", otherBackend, false); err != nil {
+				t.Fatal(err)
+			}
+
+			scheme := newTestScheme()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			for key, value := range tt.fields {
+				secret.Data[key] = []byte(value)
+			}
+
+			r := &SecretReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Scheme:          scheme,
+				Backend:         defaultBackend,
+				Backends:        registry,
+				AnnotationKey:   "test-annotation",
+				EnforceEndpoint: true,
+			}
+
+			status, err := r.handleSecret(context.Background(), secret)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.expectStatus {
+				t.Errorf("expected status %q, got %q", tt.expectStatus, status)
+			}
+
+			for name, backend := range map[string]*backends.MockBackend{"default": defaultBackend, "other": otherBackend} {
+				if called := backend.CreateUserCalls > 0; called != (name == tt.expectCalled) {
+					t.Errorf("unexpected CreateUser calls on backend %s: %d", name, backend.CreateUserCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleSecret_BucketOwnedByOtherAccount(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.BucketExistsError = fmt.Errorf("%w: test-bucket", backends.ErrBucketOwnedByOtherAccount)
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+		Recorder:      recorder,
+	}
+
+	status, err := r.handleSecret(context.Background(), secret)
+	if !errors.Is(err, backends.ErrBucketOwnedByOtherAccount) {
+		t.Fatalf("expected ErrBucketOwnedByOtherAccount, got %v", err)
+	}
+	if status != SecretStatusError {
+		t.Errorf("expected status %q, got %q", SecretStatusError, status)
+	}
+	if mockBackend.CreateBucketCalls != 0 {
+		t.Error("did not expect CreateBucket to be called")
+	}
+
+	got := drainEvents(recorder)
+	if len(got) == 0 || !strings.HasPrefix(got[len(got)-1], "Warning BucketOwnedByOtherAccount") {
+		t.Errorf("expected a BucketOwnedByOtherAccount event, got %v", got)
+	}
+}
+
+func TestParseBucketVersioning(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      map[string]string
+		expectErr bool
+		expect    bucketVersioning
+	}{
+		{name: "unset"},
+		{name: "enabled", data: map[string]string{"versioning": "Enabled"}, expect: bucketVersioning{versioning: boolPtr(true)}},
+		{name: "suspended", data: map[string]string{"VERSIONING": "false"}, expect: bucketVersioning{versioning: boolPtr(false)}},
+		{name: "object lock", data: map[string]string{"object-lock": "true"}, expect: bucketVersioning{objectLock: true}},
+		{
+			name: "retention",
+			data: map[string]string{"object-lock": "true", "retention-mode": "compliance", "retention-days": "30"},
+			expect: bucketVersioning{
+				objectLock: true,
+				retention:  backends.ObjectLockConfiguration{Mode: backends.RetentionModeCompliance, Days: 30},
+			},
+		},
+		{name: "invalid versioning", data: map[string]string{"versioning": "maybe"}, expectErr: true},
+		{name: "invalid object lock", data: map[string]string{"object-lock": "yes please"}, expectErr: true},
+		{name: "invalid retention mode", data: map[string]string{"object-lock": "true", "retention-mode": "strict", "retention-days": "1"}, expectErr: true},
+		{name: "invalid retention days", data: map[string]string{"object-lock": "true", "retention-mode": "GOVERNANCE", "retention-days": "-1"}, expectErr: true},
+		{name: "retention mode without days", data: map[string]string{"object-lock": "true", "retention-mode": "GOVERNANCE"}, expectErr: true},
+		{name: "retention without object lock", data: map[string]string{"retention-mode": "GOVERNANCE", "retention-days": "1"}, expectErr: true},
+		{name: "object lock without versioning", data: map[string]string{"object-lock": "true", "versioning": "false"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := parseBucketVersioning(tt.data)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(settings, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, settings)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_VersioningAndObjectLock(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name":    []byte("test-bucket"),
+			"access-key":     []byte("test-key"),
+			"secret-key":     []byte("test-secret"),
+			"object-lock":    []byte("true"),
+			"retention-mode": []byte("COMPLIANCE"),
+			"retention-days": []byte("7"),
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	recorder := events.NewFakeRecorder(20)
+
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+		Recorder:      recorder,
+	}
+
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if !mockBackend.Versioning["test-bucket"] {
+		t.Error("expected versioning to be enabled")
+	}
+	lock := mockBackend.ObjectLocks["test-bucket"]
+	if lock == nil || lock.Mode != backends.RetentionModeCompliance || lock.Days != 7 {
+		t.Fatalf("unexpected object lock configuration %+v", lock)
+	}
+
+	// An unchanged secret converges without writes
+	mockBackend.PutBucketVersioningCalls = 0
+	mockBackend.PutObjectLockConfigurationCalls = 0
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if mockBackend.PutBucketVersioningCalls != 0 || mockBackend.PutObjectLockConfigurationCalls != 0 {
+		t.Error("did not expect writes for unchanged settings")
+	}
+
+	// A changed setting is applied on the next pass
+	secret.Data["retention-days"] = []byte("30")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if lock := mockBackend.ObjectLocks["test-bucket"]; lock.Days != 30 {
+		t.Errorf("expected retention of 30 days, got %d", lock.Days)
+	}
+
+	// Versioning changed directly on the backend is restored
+	mockBackend.Versioning["test-bucket"] = false
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if !mockBackend.Versioning["test-bucket"] {
+		t.Error("expected versioning to be re-enabled")
+	}
+
+	// Object lock cannot be enabled on an existing bucket created without it
+	mockBackend.Buckets["plain-bucket"] = "test-key"
+	secret.Data["bucket-name"] = []byte("plain-bucket")
+	if _, err := r.handleSecret(context.Background(), secret); err == nil {
+		t.Error("expected error enabling object lock on an existing bucket")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestParseLifecycleRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expectErr bool
+		expect    []backends.LifecycleRule
+	}{
+		{name: "unset"},
+		{name: "empty list", value: "[]", expect: []backends.LifecycleRule{}},
+		{
+			name:   "yaml",
+			value:  "- id: tmp\n  prefix: tmp/\n  expirationDays: 7\n",
+			expect: []backends.LifecycleRule{{ID: "tmp", Prefix: "tmp/", ExpirationDays: 7}},
+		},
+		{
+			name:   "json",
+			value:  `[{"id": "versions", "noncurrentVersionExpirationDays": 30, "abortIncompleteMultipartUploadDays": 1}]`,
+			expect: []backends.LifecycleRule{{ID: "versions", NoncurrentVersionExpirationDays: 30, AbortIncompleteMultipartUploadDays: 1}},
+		},
+		{name: "not a list", value: "id: tmp", expectErr: true},
+		{name: "unknown field", value: "- id: tmp\n  expireDays: 7\n", expectErr: true},
+		{name: "missing id", value: "- expirationDays: 7\n", expectErr: true},
+		{name: "duplicate id", value: "- id: a\n  expirationDays: 1\n- id: a\n  expirationDays: 2\n", expectErr: true},
+		{name: "no action", value: "- id: a\n  prefix: tmp/\n", expectErr: true},
+		{name: "negative days", value: "- id: a\n  expirationDays: -1\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseLifecycleRules(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rules, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, rules)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_LifecycleRules(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name":     []byte("test-bucket"),
+			"access-key":      []byte("test-key"),
+			"secret-key":      []byte("test-secret"),
+			"lifecycle-rules": []byte("- id: tmp\n  prefix: tmp/\n  expirationDays: 7\n"),
+		},
+	}
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+	}
+
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	expected := []backends.LifecycleRule{{ID: "tmp", Prefix: "tmp/", ExpirationDays: 7}}
+	if !reflect.DeepEqual(mockBackend.Lifecycles["test-bucket"], expected) {
+		t.Fatalf("expected rules %+v, got %+v", expected, mockBackend.Lifecycles["test-bucket"])
+	}
+
+	// Unchanged rules are not rewritten
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if mockBackend.PutBucketLifecycleCalls != 1 {
+		t.Errorf("expected 1 PutBucketLifecycle call, got %d", mockBackend.PutBucketLifecycleCalls)
+	}
+
+	// Removing the field leaves the rules alone, an empty list removes them
+	delete(secret.Data, "lifecycle-rules")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if len(mockBackend.Lifecycles["test-bucket"]) != 1 {
+		t.Error("expected unmanaged rules to be kept")
+	}
+	secret.Data["lifecycle-rules"] = []byte("[]")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if _, ok := mockBackend.Lifecycles["test-bucket"]; ok || mockBackend.DeleteBucketLifecycleCalls != 1 {
+		t.Error("expected lifecycle rules to be removed")
+	}
+}
+
+func TestParseBucketQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      map[string]string
+		expectErr bool
+		expect    *backends.BucketQuota
+	}{
+		{name: "unset"},
+		{name: "bytes", data: map[string]string{"quota": "1073741824"}, expect: &backends.BucketQuota{MaxSize: 1 << 30}},
+		{name: "quantity", data: map[string]string{"QUOTA": "10Gi"}, expect: &backends.BucketQuota{MaxSize: 10 << 30}},
+		{
+			name:   "size and objects",
+			data:   map[string]string{"quota": "500M", "quota-objects": "1000"},
+			expect: &backends.BucketQuota{MaxSize: 500000000, MaxObjects: 1000},
+		},
+		{name: "objects only", data: map[string]string{"QUOTA_OBJECTS": "10"}, expect: &backends.BucketQuota{MaxObjects: 10}},
+		{name: "remove", data: map[string]string{"quota": "0"}, expect: &backends.BucketQuota{}},
+		{name: "invalid size", data: map[string]string{"quota": "lots"}, expectErr: true},
+		{name: "negative size", data: map[string]string{"quota": "-1Gi"}, expectErr: true},
+		{name: "fractional size", data: map[string]string{"quota": "0.5"}, expectErr: true},
+		{name: "invalid objects", data: map[string]string{"quota-objects": "1k"}, expectErr: true},
+		{name: "negative objects", data: map[string]string{"quota-objects": "-5"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota, err := parseBucketQuota(tt.data)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(quota, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, quota)
+			}
+		})
+	}
+}
+
+// basicBackend hides the optional capabilities of the backend it wraps
+type basicBackend struct {
+	backends.Backend
+}
+
+func TestHandleSecret_BucketClaimWithoutTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		owner        string
+		adopt        bool
+		bucketPolicy string
+		expectErr    bool
+		expectOwner  string
+	}{
+		{name: "unowned bucket", expectOwner: "test-key"},
+		{name: "own bucket", owner: "test-key", expectOwner: "test-key"},
+		{name: "owned by another access key", owner: "other-key", expectErr: true, expectOwner: "other-key"},
+		{name: "adopted from another access key", owner: "other-key", adopt: true, expectOwner: "test-key"},
+		{name: "shared access", owner: "other-key", bucketPolicy: "read-only", expectErr: true, expectOwner: "other-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Like Garage, the backend cannot tag buckets
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Buckets["test-bucket"] = tt.owner
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-secret",
+					Namespace:   "team-a",
+					UID:         "1234",
+					Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			if tt.adopt {
+				secret.Annotations[AdoptAnnotation] = "true"
+			}
+			if tt.bucketPolicy != "" {
+				secret.Data["bucket-policy"] = []byte(tt.bucketPolicy)
+			}
+			recorder := events.NewFakeRecorder(10)
+			r := &SecretReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Scheme:        scheme,
+				Backend:       basicBackend{mockBackend},
+				AnnotationKey: "s3-resource-operator.io/enabled",
+				Recorder:      recorder,
+			}
+
+			_, err := r.handleSecret(context.Background(), secret)
+			if tt.expectErr {
+				if !errors.Is(err, ErrBucketClaimConflict) {
+					t.Fatalf("expected claim conflict, got %v", err)
+				}
+				if len(mockBackend.Users) != 0 {
+					t.Errorf("expected no users to be created, got %v", mockBackend.Users)
+				}
+				if got := drainEvents(recorder); !slices.ContainsFunc(got, func(e string) bool { return strings.HasPrefix(e, "Warning BucketClaimConflict") }) {
+					t.Errorf("expected BucketClaimConflict event, got %v", got)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if owner := mockBackend.Buckets["test-bucket"]; owner != tt.expectOwner {
+				t.Errorf("expected owner %q, got %q", tt.expectOwner, owner)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_BucketQuota(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name":   []byte("test-bucket"),
+			"access-key":    []byte("test-key"),
+			"secret-key":    []byte("test-secret"),
+			"quota":         []byte("1Gi"),
+			"quota-objects": []byte("100"),
+		},
+	}
+	recorder := events.NewFakeRecorder(20)
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+		Recorder:      recorder,
+	}
+
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	expected := backends.BucketQuota{MaxSize: 1 << 30, MaxObjects: 100}
+	if mockBackend.Quotas["test-bucket"] != expected {
+		t.Fatalf("expected quota %+v, got %+v", expected, mockBackend.Quotas["test-bucket"])
+	}
+
+	// An unchanged quota is not rewritten
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if mockBackend.SetBucketQuotaCalls != 1 {
+		t.Errorf("expected 1 SetBucketQuota call, got %d", mockBackend.SetBucketQuotaCalls)
+	}
+
+	// Removing the fields leaves the quota alone, a zero quota removes it
+	delete(secret.Data, "quota")
+	delete(secret.Data, "quota-objects")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if _, ok := mockBackend.Quotas["test-bucket"]; !ok {
+		t.Error("expected unmanaged quota to be kept")
+	}
+	secret.Data["quota"] = []byte("0")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if _, ok := mockBackend.Quotas["test-bucket"]; ok {
+		t.Error("expected quota to be removed")
+	}
+
+	// Backends without quota support report a warning instead of failing
+	drainEvents(recorder)
+	secret.Data["quota"] = []byte("1Gi")
+	r.Backend = basicBackend{mockBackend}
+	status, err := r.handleSecret(context.Background(), secret)
+	if err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if status != SecretStatusReady {
+		t.Errorf("expected status %q, got %q", SecretStatusReady, status)
+	}
+	found := false
+	for _, e := range drainEvents(recorder) {
+		if strings.HasPrefix(e, "Warning Unsupported") && strings.Contains(e, "quota") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected an Unsupported warning event")
+	}
+}
+
+func TestHandleSecret_BucketQuotaWithoutObjectLimit(t *testing.T) {
+	// Like MinIO, the backend can only cap the size of a bucket
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.NoObjectQuota = true
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name":   []byte("test-bucket"),
+			"access-key":    []byte("test-key"),
+			"secret-key":    []byte("test-secret"),
+			"quota":         []byte("1Gi"),
+			"quota-objects": []byte("100"),
+		},
+	}
+	recorder := events.NewFakeRecorder(20)
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+		Recorder:      recorder,
+	}
+
+	status, err := r.handleSecret(context.Background(), secret)
+	if err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if status != SecretStatusReady {
+		t.Errorf("expected status %q, got %q", SecretStatusReady, status)
+	}
+	expected := backends.BucketQuota{MaxSize: 1 << 30}
+	if mockBackend.Quotas["test-bucket"] != expected {
+		t.Fatalf("expected quota %+v, got %+v", expected, mockBackend.Quotas["test-bucket"])
+	}
+	found := false
+	for _, e := range drainEvents(recorder) {
+		if strings.HasPrefix(e, "Warning Unsupported") && strings.Contains(e, "quota-objects") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected an Unsupported warning event for quota-objects")
+	}
+
+	// The size quota matches, so it is not rewritten
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if mockBackend.SetBucketQuotaCalls != 1 {
+		t.Errorf("expected 1 SetBucketQuota call, got %d", mockBackend.SetBucketQuotaCalls)
+	}
+}
+
+func TestParseCORSRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expectErr bool
+		expect    []backends.CORSRule
+	}{
+		{name: "unset"},
+		{
+			name:  "yaml",
+			value: "- allowedOrigins: [\"https://app.example.com\"]\n  allowedMethods: [get, put]\n  allowedHeaders: [\"*\"]\n  maxAgeSeconds: 3600\n",
+			expect: []backends.CORSRule{{
+				AllowedOrigins: []string{"https://app.example.com"},
+				AllowedMethods: []string{"GET", "PUT"},
+				AllowedHeaders: []string{"*"},
+				MaxAgeSeconds:  3600,
+			}},
+		},
+		{
+			name:   "json",
+			value:  `[{"id": "public", "allowedOrigins": ["*"], "allowedMethods": ["GET"], "exposeHeaders": ["ETag"]}]`,
+			expect: []backends.CORSRule{{ID: "public", AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, ExposeHeaders: []string{"ETag"}}},
+		},
+		{name: "empty list", value: "[]", expectErr: true},
+		{name: "not a list", value: "allowedOrigins: [\"*\"]", expectErr: true},
+		{name: "unknown field", value: "- allowedOrigins: [\"*\"]\n  allowedMethods: [GET]\n  maxAge: 10\n", expectErr: true},
+		{name: "missing origins", value: "- allowedMethods: [GET]\n", expectErr: true},
+		{name: "missing methods", value: "- allowedOrigins: [\"*\"]\n", expectErr: true},
+		{name: "unsupported method", value: "- allowedOrigins: [\"*\"]\n  allowedMethods: [PATCH]\n", expectErr: true},
+		{name: "negative max age", value: "- allowedOrigins: [\"*\"]\n  allowedMethods: [GET]\n  maxAgeSeconds: -1\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseCORSRules(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rules, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, rules)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_CORS(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+			"cors":        []byte("- allowedOrigins: [\"https://app.example.com\"]\n  allowedMethods: [GET, PUT]\n"),
+		},
+	}
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+	}
+
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	expected := []backends.CORSRule{{AllowedOrigins: []string{"https://app.example.com"}, AllowedMethods: []string{"GET", "PUT"}}}
+	if !reflect.DeepEqual(mockBackend.CORS["test-bucket"], expected) {
+		t.Fatalf("expected rules %+v, got %+v", expected, mockBackend.CORS["test-bucket"])
+	}
+	if secret.Annotations[CORSManagedAnnotation] != "true" {
+		t.Errorf("expected %s annotation to be set", CORSManagedAnnotation)
+	}
+
+	// Unchanged rules are not rewritten
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if mockBackend.PutBucketCorsCalls != 1 {
+		t.Errorf("expected 1 PutBucketCors call, got %d", mockBackend.PutBucketCorsCalls)
+	}
+
+	// Removing the field removes the configuration the operator wrote
+	delete(secret.Data, "cors")
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if _, ok := mockBackend.CORS["test-bucket"]; ok || mockBackend.DeleteBucketCorsCalls != 1 {
+		t.Error("expected CORS configuration to be removed")
+	}
+	if _, ok := secret.Annotations[CORSManagedAnnotation]; ok {
+		t.Errorf("expected %s annotation to be removed", CORSManagedAnnotation)
+	}
+
+	// A configuration the operator did not write is left alone
+	mockBackend.CORS["test-bucket"] = expected
+	if _, err := r.handleSecret(context.Background(), secret); err != nil {
+		t.Fatalf("handleSecret failed: %v", err)
+	}
+	if _, ok := mockBackend.CORS["test-bucket"]; !ok {
+		t.Error("expected unmanaged CORS configuration to be kept")
+	}
+}
+
+func TestParseEncryption(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		kmsKeyID  string
+		expectErr bool
+		expect    *backends.BucketEncryption
+	}{
+		{name: "unset"},
+		{name: "none", mode: "none"},
+		{name: "sse-s3", mode: "SSE-S3", expect: &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmAES256}},
+		{name: "aes256", mode: "AES256", expect: &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmAES256}},
+		{
+			name:     "sse-kms",
+			mode:     "sse-kms",
+			kmsKeyID: "my-key",
+			expect:   &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmKMS, KMSKeyID: "my-key"},
+		},
+		{name: "sse-kms without key", mode: "SSE-KMS", expectErr: true},
+		{name: "sse-s3 with key", mode: "SSE-S3", kmsKeyID: "my-key", expectErr: true},
+		{name: "key without mode", kmsKeyID: "my-key", expectErr: true},
+		{name: "invalid", mode: "SSE-C", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encryption, err := ParseEncryption(tt.mode, tt.kmsKeyID)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(encryption, tt.expect) {
+				t.Errorf("expected %+v, got %+v", tt.expect, encryption)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_Encryption(t *testing.T) {
+	sseS3 := &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmAES256}
+	sseKMS := backends.BucketEncryption{Algorithm: backends.SSEAlgorithmKMS, KMSKeyID: "tenant-key"}
+
+	tests := []struct {
+		name          string
+		defaultConfig *backends.BucketEncryption
+		data          map[string]string
+		expect        *backends.BucketEncryption
+	}{
+		{name: "unmanaged"},
+		{name: "operator default", defaultConfig: sseS3, expect: sseS3},
+		{name: "secret override", defaultConfig: sseS3, data: map[string]string{"encryption": "SSE-KMS", "kms-key-id": "tenant-key"}, expect: &sseKMS},
+		{name: "key id selects kms", data: map[string]string{"KMS_KEY_ID": "tenant-key"}, expect: &sseKMS},
+		{name: "opt out", defaultConfig: sseS3, data: map[string]string{"encryption": "none"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			for k, v := range tt.data {
+				secret.Data[k] = []byte(v)
+			}
+			r := &SecretReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "test-annotation",
+				Encryption:    tt.defaultConfig,
+			}
+
+			for range 2 {
+				if _, err := r.handleSecret(context.Background(), secret); err != nil {
+					t.Fatalf("handleSecret failed: %v", err)
+				}
+			}
+			got, ok := mockBackend.Encryption["test-bucket"]
+			if tt.expect == nil {
+				if ok {
+					t.Errorf("expected no encryption, got %+v", got)
+				}
+				return
+			}
+			if got != *tt.expect {
+				t.Errorf("expected encryption %+v, got %+v", *tt.expect, got)
+			}
+			// Unchanged encryption is not rewritten
+			if mockBackend.PutBucketEncryptionCalls != 1 {
+				t.Errorf("expected 1 PutBucketEncryption call, got %d", mockBackend.PutBucketEncryptionCalls)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_EncryptionRejected(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.PutBucketEncryptionError = fmt.Errorf("%w: XMinioKMSNotConfigured: KMS not configured", backends.ErrEncryptionRejected)
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"bucket-name": []byte("test-bucket"),
+			"access-key":  []byte("test-key"),
+			"secret-key":  []byte("test-secret"),
+			"encryption":  []byte("SSE-S3"),
+		},
+	}
+	recorder := events.NewFakeRecorder(20)
+	r := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "test-annotation",
+		Recorder:      recorder,
+	}
+
+	status, err := r.handleSecret(context.Background(), secret)
+	if !errors.Is(err, backends.ErrEncryptionRejected) {
+		t.Fatalf("expected ErrEncryptionRejected, got %v", err)
+	}
+	if status != SecretStatusError {
+		t.Errorf("expected status %q, got %q", SecretStatusError, status)
+	}
+	got := drainEvents(recorder)
+	if len(got) == 0 || !strings.HasPrefix(got[len(got)-1], "Warning EncryptionRejected") || !strings.Contains(got[len(got)-1], "XMinioKMSNotConfigured") {
+		t.Errorf("expected an EncryptionRejected event naming the backend error, got %v", got)
+	}
+
+	// Backends without encryption support are rejected the same way
+	mockBackend.Reset()
+	r.Backend = basicBackend{mockBackend}
+	if _, err := r.handleSecret(context.Background(), secret); !errors.Is(err, backends.ErrEncryptionRejected) {
+		t.Errorf("expected ErrEncryptionRejected, got %v", err)
+	}
+}
+
+func TestParseBucketTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expectErr bool
+		expect    map[string]string
+	}{
+		{name: "unset"},
+		{name: "yaml", value: "team: payments\ncost-center: \"42\"\n", expect: map[string]string{"team": "payments", "cost-center": "42"}},
+		{name: "json", value: `{"team": "payments"}`, expect: map[string]string{"team": "payments"}},
+		{name: "not a map", value: "- team", expectErr: true},
+		{name: "reserved prefix", value: "s3-resource-operator.io/origin: created", expectErr: true},
+		{name: "aws prefix", value: "aws:createdBy: me", expectErr: true},
+		{name: "long key", value: strings.Repeat("k", 129) + ": v", expectErr: true},
+		{name: "long value", value: "k: " + strings.Repeat("v", 257), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := parseBucketTags(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tags, tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, tags)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_BucketTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     bool
+		expectOrigin string
+	}{
+		{name: "created bucket", expectOrigin: BucketOriginCreated},
+		{name: "adopted bucket", existing: true, expectOrigin: BucketOriginAdopted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			if tt.existing {
+				mockBackend.Buckets["test-bucket"] = "test-key"
+			}
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "team-a", UID: "1234"},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+					"bucket-tags": []byte("team: payments\n"),
+				},
+			}
+			r := &SecretReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "test-annotation",
+				ClusterName:   "prod",
+			}
+
+			for range 2 {
+				if _, err := r.handleSecret(context.Background(), secret); err != nil {
+					t.Fatalf("handleSecret failed: %v", err)
+				}
+			}
+			expected := map[string]string{
+				"team":        "payments",
+				TagCluster:    "prod",
+				TagNamespace:  "team-a",
+				TagSecretName: "test-secret",
+				TagSecretUID:  "1234",
+				TagOrigin:     tt.expectOrigin,
+			}
+			if !reflect.DeepEqual(mockBackend.Tags["test-bucket"], expected) {
+				t.Errorf("expected tags %v, got %v", expected, mockBackend.Tags["test-bucket"])
+			}
+			// Unchanged tags are not rewritten
+			if mockBackend.PutBucketTaggingCalls != 1 {
+				t.Errorf("expected 1 PutBucketTagging call, got %d", mockBackend.PutBucketTaggingCalls)
+			}
+
+			// Tags removed from the secret are removed from the bucket, the origin is kept
+			delete(secret.Data, "bucket-tags")
+			if _, err := r.handleSecret(context.Background(), secret); err != nil {
+				t.Fatalf("handleSecret failed: %v", err)
+			}
+			tags := mockBackend.Tags["test-bucket"]
+			if _, ok := tags["team"]; ok || tags[TagOrigin] != tt.expectOrigin {
+				t.Errorf("unexpected tags %v", tags)
+			}
+		})
+	}
+}
+
+func TestValidateBucketName(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+	}{
+		{name: "my-app-backups"},
+		{name: "team-a.backups"},
+		{name: "abc"},
+		{name: strings.Repeat("a", 63)},
+		{name: "ab", expectErr: true},
+		{name: strings.Repeat("a", 64), expectErr: true},
+		{name: "My-Bucket", expectErr: true},
+		{name: "my_bucket", expectErr: true},
+		{name: "-bucket", expectErr: true},
+		{name: "bucket-", expectErr: true},
+		{name: "my..bucket", expectErr: true},
+		{name: "my.-bucket", expectErr: true},
+		{name: "192.168.1.1", expectErr: true},
+		{name: "xn--bucket", expectErr: true},
+		{name: "sthree-bucket", expectErr: true},
+		{name: "bucket-s3alias", expectErr: true},
+		{name: "bucket--ol-s3", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBucketName(tt.name)
+			if tt.expectErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseBucketNameTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		expectErr bool
+	}{
+		{name: "namespace prefix", template: "{{.Namespace}}-{{.BucketName}}"},
+		{name: "all fields", template: "{{.ClusterName}}-{{.Namespace}}-{{.SecretName}}-{{.BucketName}}"},
+		{name: "unknown field", template: "{{.Team}}-{{.BucketName}}", expectErr: true},
+		{name: "syntax error", template: "{{.Namespace", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBucketNameTemplate(tt.template)
+			if tt.expectErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_BucketNameTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		bucketName   string
+		expectErr    bool
+		expectBucket string
+	}{
+		{name: "namespace prefix", bucketName: "backups", expectBucket: "team-a-backups"},
+		{name: "invalid resolved name", bucketName: "Backups", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			scheme := newTestScheme()
+			tmpl, err := ParseBucketNameTemplate("{{.Namespace}}-{{.BucketName}}")
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-secret",
+					Namespace:   "team-a",
+					Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte(tt.bucketName),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			recorder := events.NewFakeRecorder(10)
+			r := &SecretReconciler{
+				Client:             client,
+				Scheme:             scheme,
+				Backend:            mockBackend,
+				AnnotationKey:      "s3-resource-operator.io/enabled",
+				BucketNameTemplate: tmpl,
+				Recorder:           recorder,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "test-secret"}}
+			_, err = r.Reconcile(context.Background(), req)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if len(mockBackend.Buckets) != 0 {
+					t.Errorf("expected no buckets, got %v", mockBackend.Buckets)
+				}
+				if got := drainEvents(recorder); len(got) == 0 || !strings.HasPrefix(got[0], "Warning InvalidField") {
+					t.Errorf("expected InvalidField event, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := mockBackend.Buckets[tt.expectBucket]; !ok {
+				t.Errorf("expected bucket %s, got %v", tt.expectBucket, mockBackend.Buckets)
+			}
+
+			var updated corev1.Secret
+			if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			if name := updated.Annotations[BucketNameAnnotation]; name != tt.expectBucket {
+				t.Errorf("expected bucket-name annotation %q, got %q", tt.expectBucket, name)
+			}
+		})
+	}
+}
+
+func TestReconcile_DeletesProvisionedBucketNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		expectGone string
+		expectKept string
+	}{
+		{name: "recorded names after a template change", annotation: "team-a-backups", expectGone: "team-a-backups", expectKept: "test-secret-backups"},
+		{name: "template without recorded names", expectGone: "test-secret-backups", expectKept: "team-a-backups"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Users["test-key"] = &backends.MockUser{AccessKey: "test-key"}
+			mockBackend.Buckets["team-a-backups"] = "test-key"
+			mockBackend.Buckets["test-secret-backups"] = "test-key"
+			scheme := newTestScheme()
+			tmpl, err := ParseBucketNameTemplate("{{.SecretName}}-{{.BucketName}}")
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+
+			now := metav1.Now()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-secret",
+					Namespace:         "team-a",
+					Finalizers:        []string{FinalizerName},
+					DeletionTimestamp: &now,
+					Annotations: map[string]string{
+						"s3-resource-operator.io/enabled": "true",
+						DeletionPolicyAnnotation:          string(DeletionPolicyDeleteAll),
+					},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte("backups"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			if tt.annotation != "" {
+				secret.Annotations[BucketNameAnnotation] = tt.annotation
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			r := &SecretReconciler{
+				Client:             client,
+				Scheme:             scheme,
+				Backend:            mockBackend,
+				AnnotationKey:      "s3-resource-operator.io/enabled",
+				BucketNameTemplate: tmpl,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "test-secret"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := mockBackend.Buckets[tt.expectGone]; ok {
+				t.Errorf("expected bucket %s to be deleted", tt.expectGone)
+			}
+			if _, ok := mockBackend.Buckets[tt.expectKept]; !ok {
+				t.Errorf("expected bucket %s to be kept", tt.expectKept)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_BucketClaim(t *testing.T) {
+	tests := []struct {
+		name         string
+		claim        map[string]string
+		claimant     client.Object
+		adopt        bool
+		scope        WatchScope
+		bucketPolicy string
+		data         map[string]string
+		encryption   *backends.BucketEncryption
+		expectErr    bool
+		expectOwner  string
+		expectClaim  string
+		expectEvent  string
+	}{
+		{
+			name:        "unclaimed bucket",
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketOwnerChanged",
+		},
+		{
+			name:        "own claim",
+			claim:       map[string]string{TagNamespace: "team-a", TagSecretName: "test-secret", TagSecretUID: "1234"},
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketOwnerChanged",
+		},
+		{
+			name:  "claimed by another secret",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			}},
+			expectErr:   true,
+			expectOwner: "other-key",
+			expectClaim: "5678",
+			expectEvent: "Warning BucketClaimConflict",
+		},
+		{
+			name:  "adopted from another secret",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			}},
+			adopt:       true,
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketAdopted",
+		},
+		{
+			name:        "claiming secret was deleted",
+			claim:       map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketOwnerChanged",
+		},
+		{
+			name:  "claiming secret is no longer annotated",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+			}},
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketOwnerChanged",
+		},
+		{
+			name:  "shared access to a claimed bucket",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: "team-a/test-secret"},
+			}},
+			bucketPolicy: "read-only",
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Normal BucketPolicyUpdated",
+		},
+		{
+			name:  "shared access ignores the default encryption",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: "team-a/test-secret"},
+			}},
+			bucketPolicy: "read-write",
+			encryption:   &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmAES256},
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Normal BucketPolicyUpdated",
+		},
+		{
+			name:  "shared access to a bucket shared with the namespace",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: "team-c/app, team-a/*"},
+			}},
+			bucketPolicy: "read-only",
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Normal BucketPolicyUpdated",
+		},
+		{
+			name:  "shared access without the owner's consent",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: "team-a/app"},
+			}},
+			bucketPolicy: "read-write",
+			expectErr:    true,
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Warning BucketClaimConflict",
+		},
+		{
+			name:         "shared access to a bucket of a deleted secret",
+			claim:        map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			bucketPolicy: "read-only",
+			expectErr:    true,
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Warning BucketClaimConflict",
+		},
+		{
+			name:  "shared access cannot change bucket settings",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: "team-a/test-secret"},
+			}},
+			bucketPolicy: "read-only",
+			data:         map[string]string{"lifecycle-rules": "- id: expire\n  expirationDays: 1\n", "quota": "1Gi"},
+			expectErr:    true,
+			expectOwner:  "other-key",
+			expectClaim:  "5678",
+			expectEvent:  "Warning BucketClaimConflict",
+		},
+		{
+			name:        "claimed in an unwatched namespace",
+			claim:       map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			scope:       WatchScope{Namespaces: []string{"team-a"}},
+			expectErr:   true,
+			expectOwner: "other-key",
+			expectClaim: "5678",
+			expectEvent: "Warning BucketClaimConflict",
+		},
+		{
+			name:  "claimed by a secret outside the label selector",
+			claim: map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+			}},
+			scope:       WatchScope{Selector: labels.SelectorFromSet(labels.Set{"team": "a"})},
+			expectErr:   true,
+			expectOwner: "other-key",
+			expectClaim: "5678",
+			expectEvent: "Warning BucketClaimConflict",
+		},
+		{
+			name:        "claiming S3Bucket was deleted",
+			claim:       map[string]string{TagKind: claimKindS3Bucket, TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			expectOwner: "test-key",
+			expectClaim: "1234",
+			expectEvent: "Normal BucketOwnerChanged",
+		},
+		{
+			name:  "claimed by an S3Bucket",
+			claim: map[string]string{TagKind: claimKindS3Bucket, TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			claimant: &s3v1alpha1.S3Bucket{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "5678",
+			}},
+			expectErr:   true,
+			expectOwner: "other-key",
+			expectClaim: "5678",
+			expectEvent: "Warning BucketClaimConflict",
+		},
+		{
+			name:        "claimed in another cluster",
+			claim:       map[string]string{TagCluster: "staging", TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "5678"},
+			expectErr:   true,
+			expectOwner: "other-key",
+			expectClaim: "5678",
+			expectEvent: "Warning BucketClaimConflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Buckets["test-bucket"] = "other-key"
+			if tt.claim != nil {
+				mockBackend.Tags["test-bucket"] = tt.claim
+			}
+			scheme := newTestScheme()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-secret",
+					Namespace:   "team-a",
+					UID:         "1234",
+					Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+				},
+				Data: map[string][]byte{
+					"bucket-name": []byte("test-bucket"),
+					"access-key":  []byte("test-key"),
+					"secret-key":  []byte("test-secret"),
+				},
+			}
+			if tt.adopt {
+				secret.Annotations[AdoptAnnotation] = "true"
+			}
+			if tt.bucketPolicy != "" {
+				secret.Data["bucket-policy"] = []byte(tt.bucketPolicy)
+			}
+			for key, value := range tt.data {
+				secret.Data[key] = []byte(value)
+			}
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret)
+			if tt.claimant != nil {
+				builder = builder.WithObjects(tt.claimant)
+			}
+			recorder := events.NewFakeRecorder(10)
+			r := &SecretReconciler{
+				Client:        builder.Build(),
+				Scheme:        scheme,
+				Backend:       mockBackend,
+				AnnotationKey: "s3-resource-operator.io/enabled",
+				Scope:         tt.scope,
+				Encryption:    tt.encryption,
+				Recorder:      recorder,
+			}
+
+			_, err := r.handleSecret(context.Background(), secret)
+			if tt.expectErr {
+				if !errors.Is(err, ErrBucketClaimConflict) {
+					t.Fatalf("expected claim conflict, got %v", err)
+				}
+				if len(mockBackend.Users) != 0 {
+					t.Errorf("expected no users to be created, got %v", mockBackend.Users)
+				}
+				if policy := mockBackend.Policies["test-bucket"]; policy != "" {
+					t.Errorf("expected no bucket policy, got %s", policy)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if owner := mockBackend.Buckets["test-bucket"]; owner != tt.expectOwner {
+				t.Errorf("expected owner %q, got %q", tt.expectOwner, owner)
+			}
+			if claim := mockBackend.Tags["test-bucket"][TagSecretUID]; claim != tt.expectClaim {
+				t.Errorf("expected bucket to be claimed by %q, got %q", tt.expectClaim, claim)
+			}
+			if tt.encryption != nil && tt.expectClaim != "1234" {
+				if _, ok := mockBackend.Encryption["test-bucket"]; ok {
+					t.Error("expected encryption of a bucket claimed by another secret to be left alone")
+				}
+			}
+			if got := drainEvents(recorder); !slices.ContainsFunc(got, func(e string) bool { return strings.HasPrefix(e, tt.expectEvent) }) {
+				t.Errorf("expected event %q, got %v", tt.expectEvent, got)
+			}
+		})
+	}
+}
+
+func TestSecretBucketNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   map[string]string
+		expect []string
+	}{
+		{name: "none", data: map[string]string{}},
+		{name: "single", data: map[string]string{"bucket-name": "data"}, expect: []string{"data"}},
+		{name: "list", data: map[string]string{"bucket-names": "data, backups,,logs"}, expect: []string{"data", "backups", "logs"}},
+		{
+			name:   "numbered",
+			data:   map[string]string{"bucket-name-2": "backups", "bucket-name-10": "logs", "bucket-name-1": "data", "bucket-name-x": "ignored"},
+			expect: []string{"data", "backups", "logs"},
+		},
+		{
+			name:   "combined",
+			data:   map[string]string{"BUCKET_NAME": "data", "BUCKET_NAMES": "backups", "BUCKET_NAME_1": "logs"},
+			expect: []string{"data", "backups", "logs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if names := secretBucketNames(tt.data); !reflect.DeepEqual(names, tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, names)
+			}
+		})
+	}
+}
+
+func TestHandleSecret_MultipleBuckets(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	// Taking over the existing backups bucket fails, the other buckets are still created
+	mockBackend.Buckets["backups"] = "other-key"
+	mockBackend.ChangeBucketOwnerError = fmt.Errorf("access denied")
+	scheme := newTestScheme()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "default",
+			Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+		},
+		Data: map[string][]byte{
+			"bucket-names": []byte("data,backups,logs,data"),
+			"access-key":   []byte("test-key"),
+			"secret-key":   []byte("test-secret"),
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	recorder := events.NewFakeRecorder(10)
+	r := &SecretReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		Backend:       mockBackend,
+		AnnotationKey: "s3-resource-operator.io/enabled",
+		Recorder:      recorder,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-secret"}}
+	_, err := r.Reconcile(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "bucket backups:") {
+		t.Fatalf("expected error for bucket backups, got %v", err)
+	}
+	for _, bucket := range []string{"data", "logs"} {
+		if owner := mockBackend.Buckets[bucket]; owner != "test-key" {
+			t.Errorf("expected bucket %s owned by test-key, got %q", bucket, owner)
+		}
+	}
+
+	got := drainEvents(recorder)
+	expected := []string{"Normal UserCreated", "Normal BucketCreated", "Warning ReconcileFailed bucket backups:", "Normal BucketCreated"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, got)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(got[i], prefix) {
+			t.Errorf("expected event %q to start with %q", got[i], prefix)
+		}
+	}
+
+	var updated corev1.Secret
+	if err := client.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if status := updated.Annotations[StatusAnnotation]; status != string(SecretStatusError) {
+		t.Errorf("expected status %q, got %q", SecretStatusError, status)
+	}
+	if names := updated.Annotations[BucketNameAnnotation]; names != "data,backups,logs" {
+		t.Errorf("expected bucket-name annotation %q, got %q", "data,backups,logs", names)
+	}
+
+	// Deleting the secret removes every bucket it owns
+	updated.Annotations[DeletionPolicyAnnotation] = string(DeletionPolicyDeleteAll)
+	if err := client.Update(context.Background(), &updated); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if err := client.Delete(context.Background(), &updated); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(mockBackend.Buckets, map[string]string{"backups": "other-key"}) {
+		t.Errorf("expected only the backups bucket to be kept, got %v", mockBackend.Buckets)
+	}
+}
+
+func TestWatchScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces string
+		exclude    string
+		selector   string
+		expectErr  bool
+		included   []*corev1.Secret
+		excluded   []*corev1.Secret
+	}{
+		{
+			name:     "everything",
+			included: []*corev1.Secret{scopedSecret("team-a", nil), scopedSecret("kube-system", nil)},
+		},
+		{
+			name:       "watched namespaces",
+			namespaces: "team-a, team-b",
+			included:   []*corev1.Secret{scopedSecret("team-a", nil), scopedSecret("team-b", nil)},
+			excluded:   []*corev1.Secret{scopedSecret("team-c", nil)},
+		},
+		{
+			name:     "excluded namespaces",
+			exclude:  "kube-system",
+			included: []*corev1.Secret{scopedSecret("team-a", nil)},
+			excluded: []*corev1.Secret{scopedSecret("kube-system", nil)},
+		},
+		{
+			name:       "excluded namespace is not watched",
+			namespaces: "team-a,team-b",
+			exclude:    "team-b",
+			included:   []*corev1.Secret{scopedSecret("team-a", nil)},
+			excluded:   []*corev1.Secret{scopedSecret("team-b", nil)},
+		},
+		{
+			name:     "label selector",
+			selector: "team=payments",
+			included: []*corev1.Secret{scopedSecret("team-a", map[string]string{"team": "payments"})},
+			excluded: []*corev1.Secret{scopedSecret("team-a", nil), scopedSecret("team-a", map[string]string{"team": "search"})},
+		},
+		{name: "invalid selector", selector: "team in payments", expectErr: true},
+		{name: "every namespace excluded", namespaces: "team-a", exclude: "team-a", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := NewWatchScope(tt.namespaces, tt.exclude, tt.selector)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, secret := range tt.included {
+				if !scope.Includes(secret) {
+					t.Errorf("expected secret in %s with labels %v to be included", secret.Namespace, secret.Labels)
+				}
+			}
+			for _, secret := range tt.excluded {
+				if scope.Includes(secret) {
+					t.Errorf("expected secret in %s with labels %v to be excluded", secret.Namespace, secret.Labels)
+				}
+			}
+		})
+	}
+}
+
+func scopedSecret(namespace string, labels map[string]string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: namespace, Labels: labels}}
+}
+
+func TestWatchScope_CacheOptions(t *testing.T) {
+	scope, err := NewWatchScope("team-a,team-b", "", "team=payments")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := scope.CacheOptions()
+	if len(opts.DefaultNamespaces) != 2 {
+		t.Errorf("expected 2 cached namespaces, got %v", opts.DefaultNamespaces)
+	}
+	if _, ok := opts.DefaultNamespaces["team-a"]; !ok {
+		t.Errorf("expected namespace team-a to be cached, got %v", opts.DefaultNamespaces)
+	}
+	if len(opts.ByObject) != 1 {
+		t.Errorf("expected a label selector for secrets, got %v", opts.ByObject)
+	}
+
+	scope, err = NewWatchScope("", "kube-system,kube-public", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts = scope.CacheOptions()
+	config, ok := opts.DefaultNamespaces[""]
+	if !ok || config.FieldSelector == nil {
+		t.Fatalf("expected a field selector for all namespaces, got %v", opts.DefaultNamespaces)
+	}
+	if selector := config.FieldSelector.String(); selector != "metadata.namespace!=kube-system,metadata.namespace!=kube-public" {
+		t.Errorf("unexpected field selector %q", selector)
+	}
+	if opts.ByObject != nil {
+		t.Errorf("expected no label selector, got %v", opts.ByObject)
+	}
+}
+
+func TestSecretValidator(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		data        map[string]string
+		expectErr   string
+	}{
+		{
+			name: "not annotated",
+			data: map[string]string{"bucket-name": "Invalid_Bucket"},
+		},
+		{
+			name:        "valid",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket", "access-key": "test-key", "secret-key": "test-secret", "user-id": "1000"},
+		},
+		{
+			name:        "missing fields",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket"},
+			expectErr:   "missing required fields",
+		},
+		{
+			name:        "generated credentials",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true", GenerateCredentialsAnnotation: "true"},
+			data:        map[string]string{"bucket-name": "test-bucket"},
+		},
+		{
+			name:        "invalid bucket name",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "Test_Bucket", "access-key": "test-key", "secret-key": "test-secret"},
+			expectErr:   "bucket name",
+		},
+		{
+			name:        "non-integer user id",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket", "access-key": "test-key", "secret-key": "test-secret", "user-id": "12abc"},
+			expectErr:   "user-id must be an integer",
+		},
+		{
+			name:        "non-integer group id",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket", "access-key": "test-key", "secret-key": "test-secret", "group-id": "staff"},
+			expectErr:   "group-id must be an integer",
+		},
+		{
+			name:        "endpoint mismatch",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket", "access-key": "test-key", "secret-key": "test-secret", "endpoint-url": "http://other-server:9000"},
+			expectErr:   "does not match operator endpoint",
+		},
+		{
+			name:        "invalid quota",
+			annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+			data:        map[string]string{"bucket-name": "test-bucket", "access-key": "test-key", "secret-key": "test-secret", "quota": "lots"},
+			expectErr:   "quota",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default", Annotations: tt.annotations},
+				StringData: tt.data,
+			}
+			validator := &secretValidator{reconciler: &SecretReconciler{
+				Backend:         backends.NewMockBackend("http://localhost:9000"),
+				AnnotationKey:   "s3-resource-operator.io/enabled",
+				EnforceEndpoint: true,
+			}}
+
+			_, err := validator.ValidateCreate(context.Background(), secret)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSecretValidator_Update(t *testing.T) {
+	validator := &secretValidator{reconciler: &SecretReconciler{
+		Backend:       backends.NewMockBackend("http://localhost:9000"),
+		AnnotationKey: "s3-resource-operator.io/enabled",
+	}}
+	invalid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "default",
+			Annotations: map[string]string{"s3-resource-operator.io/enabled": "true"},
+		},
+		Data: map[string][]byte{"bucket-name": []byte("test-bucket")},
+	}
+
+	// The operator can still write the status of a secret that was invalid before
+	withStatus := invalid.DeepCopy()
+	withStatus.Annotations[StatusAnnotation] = string(SecretStatusError)
+	if _, err := validator.ValidateUpdate(context.Background(), invalid, withStatus); err != nil {
+		t.Errorf("unexpected error for status update: %v", err)
+	}
+
+	// Enabling an invalid secret is rejected
+	disabled := invalid.DeepCopy()
+	disabled.Annotations = nil
+	if _, err := validator.ValidateUpdate(context.Background(), disabled, invalid); err == nil {
+		t.Error("expected error when enabling an invalid secret, got nil")
+	}
+
+	// Changing the data is validated
+	changed := invalid.DeepCopy()
+	changed.Data["access-key"] = []byte("test-key")
+	if _, err := validator.ValidateUpdate(context.Background(), invalid, changed); err == nil {
+		t.Error("expected error for invalid data change, got nil")
+	}
+}
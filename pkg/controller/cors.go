@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// CORSManagedAnnotation marks secrets whose bucket CORS configuration was written by the
+// operator, so the configuration is removed when the cors field disappears
+const CORSManagedAnnotation = "s3-resource-operator.io/cors-managed"
+
+// corsFields hold the CORS rules of a bucket as a YAML or JSON list
+var corsFields = []string{"cors", "CORS"}
+
+// corsMethods are the HTTP methods S3 accepts in CORS rules
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// parseCORSRules validates the cors field. It returns nil if the field is not set.
+func parseCORSRules(value string) ([]backends.CORSRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	rules := []backends.CORSRule{}
+	if err := yaml.UnmarshalStrict([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("cors is not a valid list of rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("cors needs at least one rule, remove the field to remove the CORS configuration")
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if len(rule.AllowedOrigins) == 0 {
+			return nil, fmt.Errorf("cors: rule %d has no allowedOrigins", i+1)
+		}
+		if len(rule.AllowedMethods) == 0 {
+			return nil, fmt.Errorf("cors: rule %d has no allowedMethods", i+1)
+		}
+		for j, method := range rule.AllowedMethods {
+			rule.AllowedMethods[j] = strings.ToUpper(method)
+			if !slices.Contains(corsMethods, rule.AllowedMethods[j]) {
+				return nil, fmt.Errorf("cors: rule %d has unsupported method %q, allowed are %s", i+1, method, strings.Join(corsMethods, ", "))
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			return nil, fmt.Errorf("cors: rule %d has a negative maxAgeSeconds", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// reconcileBucketCors makes the CORS configuration of a bucket match rules. A nil list removes
+// a configuration the operator wrote before (managed) and otherwise leaves it alone. Unchanged
+// rules are not rewritten.
+func reconcileBucketCors(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, rules []backends.CORSRule, managed bool) error {
+	logger := log.FromContext(ctx)
+
+	if rules == nil && !managed {
+		return nil
+	}
+
+	manager, ok := backend.(backends.CORSManager)
+	if !ok {
+		if rules != nil {
+			logger.Info("Backend does not support CORS, skipping", "bucket", bucketName)
+			recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support CORS, ignoring cors")
+		}
+		return nil
+	}
+
+	current, err := manager.GetBucketCors(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket cors: %w", err)
+	}
+
+	if rules == nil {
+		if current == nil {
+			return nil
+		}
+		if err := manager.DeleteBucketCors(ctx, bucketName); err != nil {
+			return fmt.Errorf("failed to delete bucket cors: %w", err)
+		}
+		logger.Info("Removed bucket CORS configuration", "bucket", bucketName)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketCORSUpdated, "Removed CORS configuration of bucket %s", bucketName)
+		return nil
+	}
+
+	if corsRulesEqual(current, rules) {
+		return nil
+	}
+	if err := manager.PutBucketCors(ctx, bucketName, rules); err != nil {
+		return fmt.Errorf("failed to put bucket cors: %w", err)
+	}
+	logger.Info("Updated bucket CORS configuration", "bucket", bucketName, "rules", len(rules))
+	recordEvent(corev1.EventTypeNormal, ReasonBucketCORSUpdated, "Updated CORS configuration of bucket %s", bucketName)
+	return nil
+}
+
+// corsRulesEqual compares two rule lists. The order matters, browsers use the first matching rule.
+func corsRulesEqual(a, b []backends.CORSRule) bool {
+	return slices.EqualFunc(a, b, func(x, y backends.CORSRule) bool {
+		return x.ID == y.ID &&
+			slices.Equal(x.AllowedOrigins, y.AllowedOrigins) &&
+			slices.Equal(x.AllowedMethods, y.AllowedMethods) &&
+			slices.Equal(x.AllowedHeaders, y.AllowedHeaders) &&
+			slices.Equal(x.ExposeHeaders, y.ExposeHeaders) &&
+			x.MaxAgeSeconds == y.MaxAgeSeconds
+	})
+}
+
+// setCORSManaged records on the secret whether the operator manages the bucket's CORS configuration
+func (r *SecretReconciler) setCORSManaged(ctx context.Context, secret *corev1.Secret, managed bool) error {
+	if _, ok := secret.Annotations[CORSManagedAnnotation]; ok == managed {
+		return nil
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	if managed {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[CORSManagedAnnotation] = "true"
+	} else {
+		delete(secret.Annotations, CORSManagedAnnotation)
+	}
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return fmt.Errorf("failed to update %s annotation: %w", CORSManagedAnnotation, err)
+	}
+	return nil
+}
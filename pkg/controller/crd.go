@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CRDFinalizerName is added to S3User and S3Bucket resources so backend resources can be cleaned up on deletion
+const CRDFinalizerName = "s3-resource-operator.io/crd-finalizer"
+
+// errMissingCredentials is returned when a referenced secret has no access key or secret key
+var errMissingCredentials = errors.New("secret is missing required fields (access-key, secret-key)")
+
+// errOtherBackend is returned for secrets of custom resources that select a backend other than
+// the default one; custom resources are only provisioned on the default backend
+var errOtherBackend = errors.New("custom resources are only provisioned on the default backend")
+
+// readCredentials returns the access key and secret key of a secret referenced by a custom
+// resource. Secrets whose backend or endpoint-url fields select a backend of the registry other
+// than backend are rejected with errOtherBackend.
+func readCredentials(ctx context.Context, c client.Reader, registry *backends.Registry, backend backends.Backend, namespace string, ref s3v1alpha1.SecretReference) (string, string, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+		return "", "", err
+	}
+
+	data, err := decodeSecretData(&secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	routed, err := routeBackend(registry, backend, false, data)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", errOtherBackend, err)
+	}
+	if routed != backend {
+		return "", "", fmt.Errorf("%w, but secret %s selects %s", errOtherBackend, ref.Name, routed.GetEndpointURL())
+	}
+
+	accessKey := getField(data, accessKeyFields...)
+	secretKey := getField(data, secretKeyFields...)
+	if accessKey == "" || secretKey == "" {
+		return "", "", errMissingCredentials
+	}
+	return accessKey, secretKey, nil
+}
+
+// credentialsErrorReason maps a readCredentials error to a Ready condition reason
+func credentialsErrorReason(err error) string {
+	switch {
+	case apierrors.IsNotFound(err):
+		return s3v1alpha1.ReasonSecretNotFound
+	case errors.Is(err, errMissingCredentials), errors.Is(err, errOtherBackend):
+		return s3v1alpha1.ReasonInvalidSpec
+	default:
+		return s3v1alpha1.ReasonBackendError
+	}
+}
+
+// setReadyCondition records the outcome of a reconciliation in a conditions list
+func setReadyCondition(conditions *[]metav1.Condition, generation int64, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               s3v1alpha1.ConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// isResync reports whether a custom resource was reconciled successfully at its current generation
+func isResync(conditions []metav1.Condition, generation, observedGeneration int64) bool {
+	return observedGeneration == generation && meta.IsStatusConditionTrue(conditions, s3v1alpha1.ConditionReady)
+}
@@ -0,0 +1,459 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func newCredentialsSecret(name, accessKey, secretKey string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data: map[string][]byte{
+			"access-key": []byte(accessKey),
+			"secret-key": []byte(secretKey),
+		},
+	}
+}
+
+func withSecretField(secret *corev1.Secret, key, value string) *corev1.Secret {
+	secret.Data[key] = []byte(value)
+	return secret
+}
+
+func newCRDTestClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(objs...).
+		WithStatusSubresource(&s3v1alpha1.S3User{}, &s3v1alpha1.S3Bucket{}).
+		Build()
+}
+
+func assertReady(t *testing.T, conditions []metav1.Condition, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	cond := meta.FindStatusCondition(conditions, s3v1alpha1.ConditionReady)
+	if cond == nil {
+		t.Fatal("expected Ready condition to be set")
+	}
+	if cond.Status != status || cond.Reason != reason {
+		t.Errorf("expected Ready=%s (%s), got Ready=%s (%s): %s", status, reason, cond.Status, cond.Reason, cond.Message)
+	}
+}
+
+func TestS3UserReconcile_CreatesUser(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	user := &s3v1alpha1.S3User{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: s3v1alpha1.S3UserSpec{
+			SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"},
+			Role:      "user",
+		},
+	}
+	fakeClient := newCRDTestClient(user, newCredentialsSecret("app-creds", "AKAPP", "secret"))
+	r := NewS3UserReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if mockBackend.Users["AKAPP"] == nil {
+		t.Fatal("expected user to be created")
+	}
+	if role := mockBackend.Users["AKAPP"].Role; role == nil || *role != "user" {
+		t.Errorf("expected role 'user', got %v", role)
+	}
+
+	var updated s3v1alpha1.S3User
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get S3User: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(&updated, CRDFinalizerName) {
+		t.Error("expected finalizer to be added")
+	}
+	if updated.Status.AccessKey != "AKAPP" {
+		t.Errorf("expected status access key AKAPP, got %q", updated.Status.AccessKey)
+	}
+	assertReady(t, updated.Status.Conditions, metav1.ConditionTrue, s3v1alpha1.ReasonReconciled)
+}
+
+func TestS3UserReconcile_SecretProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		reason string
+	}{
+		{name: "secret not found", reason: s3v1alpha1.ReasonSecretNotFound},
+		{name: "missing secret key", secret: newCredentialsSecret("app-creds", "AKAPP", ""), reason: s3v1alpha1.ReasonInvalidSpec},
+		{name: "named backend", secret: withSecretField(newCredentialsSecret("app-creds", "AKAPP", "secret"), "backend", "other"), reason: s3v1alpha1.ReasonInvalidSpec},
+		{name: "unknown backend", secret: withSecretField(newCredentialsSecret("app-creds", "AKAPP", "secret"), "backend", "unknown"), reason: s3v1alpha1.ReasonInvalidSpec},
+		{name: "endpoint of another backend", secret: withSecretField(newCredentialsSecret("app-creds", "AKAPP", "secret"), "endpoint-url", "http://other:9000"), reason: s3v1alpha1.ReasonInvalidSpec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			otherBackend := backends.NewMockBackend("http://other:9000")
+			registry := backends.NewRegistry()
+			if err := registry.Register("default", mockBackend, true); err != nil {
+				t.Fatalf("failed to register backend: %v", err)
+			}
+			if err := registry.Register("other", otherBackend, false); err != nil {
+				t.Fatalf("failed to register backend: %v", err)
+			}
+			objs := []client.Object{&s3v1alpha1.S3User{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       s3v1alpha1.S3UserSpec{SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"}},
+			}}
+			if tt.secret != nil {
+				objs = append(objs, tt.secret)
+			}
+			fakeClient := newCRDTestClient(objs...)
+			r := NewS3UserReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+			r.Backends = registry
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			if mockBackend.CreateUserCalls != 0 || otherBackend.CreateUserCalls != 0 {
+				t.Error("expected no user to be created")
+			}
+
+			var updated s3v1alpha1.S3User
+			if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get S3User: %v", err)
+			}
+			assertReady(t, updated.Status.Conditions, metav1.ConditionFalse, tt.reason)
+		})
+	}
+}
+
+func TestS3UserReconcile_DeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      s3v1alpha1.UserDeletionPolicy
+		wantDeleted bool
+	}{
+		{name: "retain", policy: s3v1alpha1.UserDeletionPolicyRetain},
+		{name: "default", policy: ""},
+		{name: "delete", policy: s3v1alpha1.UserDeletionPolicyDelete, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Users["AKAPP"] = &backends.MockUser{AccessKey: "AKAPP", SecretKey: "secret"}
+
+			now := metav1.Now()
+			user := &s3v1alpha1.S3User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "app",
+					Namespace:         "default",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{CRDFinalizerName},
+				},
+				Spec: s3v1alpha1.S3UserSpec{
+					SecretRef:      s3v1alpha1.SecretReference{Name: "app-creds"},
+					DeletionPolicy: tt.policy,
+				},
+				Status: s3v1alpha1.S3UserStatus{AccessKey: "AKAPP"},
+			}
+			fakeClient := newCRDTestClient(user)
+			r := NewS3UserReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			_, exists := mockBackend.Users["AKAPP"]
+			if exists == tt.wantDeleted {
+				t.Errorf("expected user deleted=%v", tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestCRDReconcile_CredentialsOutsideLabelSelector(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	user := &s3v1alpha1.S3User{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       s3v1alpha1.S3UserSpec{SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"}},
+	}
+	bucket := &s3v1alpha1.S3Bucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec:       s3v1alpha1.S3BucketSpec{SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"}},
+	}
+	apiClient := newCRDTestClient(user, bucket, newCredentialsSecret("app-creds", "AKAPP", "secret"))
+	// The label-selector cache does not hold the unlabeled credentials secret
+	cachedClient := interceptor.NewClient(apiClient.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.Secret); ok {
+				return apierrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	userReconciler := NewS3UserReconciler(cachedClient, apiClient.Scheme(), mockBackend)
+	userReconciler.APIReader = apiClient
+	bucketReconciler := NewS3BucketReconciler(cachedClient, apiClient.Scheme(), mockBackend)
+	bucketReconciler.APIReader = apiClient
+
+	userReq := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+	if _, err := userReconciler.Reconcile(context.Background(), userReq); err != nil {
+		t.Fatalf("S3User Reconcile failed: %v", err)
+	}
+	bucketReq := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "data"}}
+	if _, err := bucketReconciler.Reconcile(context.Background(), bucketReq); err != nil {
+		t.Fatalf("S3Bucket Reconcile failed: %v", err)
+	}
+
+	if mockBackend.Users["AKAPP"] == nil {
+		t.Error("expected user to be created")
+	}
+	if owner := mockBackend.Buckets["data"]; owner != "AKAPP" {
+		t.Errorf("expected bucket owned by AKAPP, got %q", owner)
+	}
+	var updated s3v1alpha1.S3Bucket
+	if err := apiClient.Get(context.Background(), bucketReq.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get S3Bucket: %v", err)
+	}
+	assertReady(t, updated.Status.Conditions, metav1.ConditionTrue, s3v1alpha1.ReasonReconciled)
+}
+
+func TestS3BucketReconcile_CreatesBucket(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	mockBackend.Users["AKAPP"] = &backends.MockUser{AccessKey: "AKAPP", SecretKey: "secret"}
+
+	bucket := &s3v1alpha1.S3Bucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec: s3v1alpha1.S3BucketSpec{
+			SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"},
+			Policy:    "public-read",
+		},
+	}
+	fakeClient := newCRDTestClient(bucket, newCredentialsSecret("app-creds", "AKAPP", "secret"))
+	r := NewS3BucketReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "data"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if owner := mockBackend.Buckets["data"]; owner != "AKAPP" {
+		t.Errorf("expected bucket 'data' owned by AKAPP, got %q", owner)
+	}
+	if mockBackend.Policies["data"] == "" {
+		t.Error("expected bucket policy to be set")
+	}
+
+	var updated s3v1alpha1.S3Bucket
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get S3Bucket: %v", err)
+	}
+	if updated.Status.BucketName != "data" || updated.Status.Owner != "AKAPP" {
+		t.Errorf("unexpected status: %+v", updated.Status)
+	}
+	assertReady(t, updated.Status.Conditions, metav1.ConditionTrue, s3v1alpha1.ReasonReconciled)
+}
+
+func TestS3BucketReconcile_BucketClaim(t *testing.T) {
+	tests := []struct {
+		name         string
+		adopt        bool
+		policy       string
+		shareWith    string
+		expectReason string
+		expectOwner  string
+		expectClaim  string
+	}{
+		{
+			name:         "claimed by a secret",
+			expectReason: s3v1alpha1.ReasonBucketClaimConflict,
+			expectOwner:  "AKOTHER",
+			expectClaim:  "secret-uid",
+		},
+		{
+			name:         "adopted from a secret",
+			adopt:        true,
+			expectReason: s3v1alpha1.ReasonReconciled,
+			expectOwner:  "AKAPP",
+			expectClaim:  "bucket-uid",
+		},
+		{
+			name:         "shared by a secret",
+			policy:       "read-only",
+			shareWith:    "default/data",
+			expectReason: s3v1alpha1.ReasonReconciled,
+			expectOwner:  "AKOTHER",
+			expectClaim:  "secret-uid",
+		},
+		{
+			name:         "not shared by a secret",
+			policy:       "read-only",
+			shareWith:    "default/other",
+			expectReason: s3v1alpha1.ReasonBucketClaimConflict,
+			expectOwner:  "AKOTHER",
+			expectClaim:  "secret-uid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Users["AKAPP"] = &backends.MockUser{AccessKey: "AKAPP", SecretKey: "secret"}
+			mockBackend.Buckets["data"] = "AKOTHER"
+			mockBackend.Tags["data"] = map[string]string{TagNamespace: "team-b", TagSecretName: "other", TagSecretUID: "secret-uid"}
+
+			claimant := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "other", Namespace: "team-b", UID: "secret-uid",
+				Annotations: map[string]string{"s3-resource-operator.io/enabled": "true", ShareWithAnnotation: tt.shareWith},
+			}}
+			bucket := &s3v1alpha1.S3Bucket{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default", UID: "bucket-uid"},
+				Spec:       s3v1alpha1.S3BucketSpec{SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"}, Policy: tt.policy},
+			}
+			if tt.adopt {
+				bucket.Annotations = map[string]string{AdoptAnnotation: "true"}
+			}
+			fakeClient := newCRDTestClient(bucket, claimant, newCredentialsSecret("app-creds", "AKAPP", "secret"))
+			r := NewS3BucketReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+			r.AnnotationKey = "s3-resource-operator.io/enabled"
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "data"}}
+			_, _ = r.Reconcile(context.Background(), req)
+
+			if owner := mockBackend.Buckets["data"]; owner != tt.expectOwner {
+				t.Errorf("expected owner %q, got %q", tt.expectOwner, owner)
+			}
+			if claim := mockBackend.Tags["data"][TagSecretUID]; claim != tt.expectClaim {
+				t.Errorf("expected bucket to be claimed by %q, got %q", tt.expectClaim, claim)
+			}
+			if tt.adopt && mockBackend.Tags["data"][TagKind] != claimKindS3Bucket {
+				t.Errorf("expected kind tag %s, got %v", claimKindS3Bucket, mockBackend.Tags["data"])
+			}
+
+			var updated s3v1alpha1.S3Bucket
+			if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+				t.Fatalf("failed to get S3Bucket: %v", err)
+			}
+			status := metav1.ConditionFalse
+			if tt.expectReason == s3v1alpha1.ReasonReconciled {
+				status = metav1.ConditionTrue
+			}
+			assertReady(t, updated.Status.Conditions, status, tt.expectReason)
+		})
+	}
+}
+
+func TestS3BucketReconcile_OwnerMissing(t *testing.T) {
+	mockBackend := backends.NewMockBackend("http://localhost:9000")
+	bucket := &s3v1alpha1.S3Bucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Spec: s3v1alpha1.S3BucketSpec{
+			BucketName: "app-data",
+			SecretRef:  s3v1alpha1.SecretReference{Name: "app-creds"},
+		},
+	}
+	fakeClient := newCRDTestClient(bucket, newCredentialsSecret("app-creds", "AKAPP", "secret"))
+	r := NewS3BucketReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "data"}}
+	if _, err := r.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("expected error when the owner does not exist")
+	}
+
+	if mockBackend.CreateBucketCalls != 0 {
+		t.Error("expected no bucket to be created")
+	}
+
+	var updated s3v1alpha1.S3Bucket
+	if err := fakeClient.Get(context.Background(), req.NamespacedName, &updated); err != nil {
+		t.Fatalf("failed to get S3Bucket: %v", err)
+	}
+	assertReady(t, updated.Status.Conditions, metav1.ConditionFalse, s3v1alpha1.ReasonBackendError)
+}
+
+func TestS3BucketReconcile_DeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      s3v1alpha1.BucketDeletionPolicy
+		nonEmpty    bool
+		wantDeleted bool
+	}{
+		{name: "retain", policy: s3v1alpha1.BucketDeletionPolicyRetain},
+		{name: "delete empty bucket", policy: s3v1alpha1.BucketDeletionPolicyDelete, wantDeleted: true},
+		{name: "delete keeps non-empty bucket", policy: s3v1alpha1.BucketDeletionPolicyDelete, nonEmpty: true},
+		{name: "purge non-empty bucket", policy: s3v1alpha1.BucketDeletionPolicyPurge, nonEmpty: true, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackend := backends.NewMockBackend("http://localhost:9000")
+			mockBackend.Buckets["app-data"] = "AKAPP"
+			mockBackend.NonEmptyBuckets["app-data"] = tt.nonEmpty
+
+			now := metav1.Now()
+			bucket := &s3v1alpha1.S3Bucket{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "data",
+					Namespace:         "default",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{CRDFinalizerName},
+				},
+				Spec: s3v1alpha1.S3BucketSpec{
+					BucketName:     "app-data",
+					SecretRef:      s3v1alpha1.SecretReference{Name: "app-creds"},
+					DeletionPolicy: tt.policy,
+				},
+				Status: s3v1alpha1.S3BucketStatus{BucketName: "app-data", Owner: "AKAPP"},
+			}
+			fakeClient := newCRDTestClient(bucket)
+			r := NewS3BucketReconciler(fakeClient, fakeClient.Scheme(), mockBackend)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "data"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			_, exists := mockBackend.Buckets["app-data"]
+			if exists == tt.wantDeleted {
+				t.Errorf("expected bucket deleted=%v", tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestS3BucketReconcile_SecretMapping(t *testing.T) {
+	fakeClient := newCRDTestClient(
+		&s3v1alpha1.S3Bucket{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+			Spec:       s3v1alpha1.S3BucketSpec{SecretRef: s3v1alpha1.SecretReference{Name: "app-creds"}},
+		},
+		&s3v1alpha1.S3Bucket{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"},
+			Spec:       s3v1alpha1.S3BucketSpec{SecretRef: s3v1alpha1.SecretReference{Name: "other-creds"}},
+		},
+	)
+	r := NewS3BucketReconciler(fakeClient, fakeClient.Scheme(), backends.NewMockBackend("http://localhost:9000"))
+
+	requests := r.bucketsForSecret(context.Background(), newCredentialsSecret("app-creds", "AKAPP", "secret"))
+	if len(requests) != 1 || requests[0].Name != "a" {
+		t.Errorf("expected only bucket 'a' to be enqueued, got %v", requests)
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GenerateCredentialsAnnotation makes the operator generate a missing access key or secret key
+const GenerateCredentialsAnnotation = "s3-resource-operator.io/generate-credentials"
+
+// generatesCredentials reports whether a secret opted in to generated credentials
+func generatesCredentials(secret *corev1.Secret) bool {
+	enabled, err := strconv.ParseBool(secret.Annotations[GenerateCredentialsAnnotation])
+	return err == nil && enabled
+}
+
+// ensureCredentials generates the access key and secret key of an opted-in secret if they
+// are missing, writes them to the secret and adds them to data
+func (r *SecretReconciler) ensureCredentials(ctx context.Context, backend backends.Backend, secret *corev1.Secret, data map[string]string, recordEvent eventFunc) error {
+	if !generatesCredentials(secret) {
+		return nil
+	}
+
+	generated := make(map[string]string)
+	if getField(data, accessKeyFields...) == "" {
+		accessKey, err := backends.GenerateAccessKey(backend)
+		if err != nil {
+			return fmt.Errorf("failed to generate access key: %w", err)
+		}
+		generated[accessKeyFields[0]] = accessKey
+	}
+	if getField(data, secretKeyFields...) == "" {
+		secretKey, err := backends.GenerateSecretKey(backend)
+		if err != nil {
+			return fmt.Errorf("failed to generate secret key: %w", err)
+		}
+		generated[secretKeyFields[0]] = secretKey
+	}
+	if len(generated) == 0 {
+		return nil
+	}
+
+	// The optimistic lock makes sure concurrent writers cannot end up with different credentials
+	patch := client.MergeFromWithOptions(secret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	for key, value := range generated {
+		secret.Data[key] = []byte(value)
+	}
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return fmt.Errorf("failed to write generated credentials: %w", err)
+	}
+
+	for key, value := range generated {
+		data[key] = value
+	}
+
+	log.FromContext(ctx).Info("Generated credentials", "secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+	recordEvent(corev1.EventTypeNormal, ReasonCredentialsGenerated, "Generated %s", generatedFields(generated))
+	return nil
+}
+
+func generatedFields(generated map[string]string) string {
+	switch {
+	case len(generated) == 2:
+		return "access key and secret key"
+	case generated[accessKeyFields[0]] != "":
+		return "access key"
+	default:
+		return "secret key"
+	}
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// FinalizerName is added to annotated secrets so backend resources can be cleaned up on deletion
+	FinalizerName = "s3-resource-operator.io/finalizer"
+
+	// DeletionPolicyAnnotation overrides the operator's default deletion policy for a single secret
+	DeletionPolicyAnnotation = "s3-resource-operator.io/deletion-policy"
+)
+
+// DeletionPolicy controls what happens to backend resources when a secret is deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain keeps both the user and the bucket
+	DeletionPolicyRetain DeletionPolicy = "retain"
+	// DeletionPolicyDeleteUser deletes the user and keeps the bucket
+	DeletionPolicyDeleteUser DeletionPolicy = "delete-user"
+	// DeletionPolicyDeleteUserAndEmptyBucket deletes the user and the bucket if it contains no objects
+	DeletionPolicyDeleteUserAndEmptyBucket DeletionPolicy = "delete-user-and-empty-bucket"
+	// DeletionPolicyDeleteAll deletes the user, all objects in the bucket and the bucket itself
+	DeletionPolicyDeleteAll DeletionPolicy = "delete-all"
+)
+
+// ParseDeletionPolicy validates a deletion policy value
+func ParseDeletionPolicy(value string) (DeletionPolicy, error) {
+	switch policy := DeletionPolicy(value); policy {
+	case DeletionPolicyRetain, DeletionPolicyDeleteUser, DeletionPolicyDeleteUserAndEmptyBucket, DeletionPolicyDeleteAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid deletion policy %q (valid: %s, %s, %s, %s)", value,
+			DeletionPolicyRetain, DeletionPolicyDeleteUser, DeletionPolicyDeleteUserAndEmptyBucket, DeletionPolicyDeleteAll)
+	}
+}
+
+// deletionPolicy returns the effective deletion policy for a secret.
+// Invalid annotation values fall back to retain so that nothing is deleted by accident.
+func (r *SecretReconciler) deletionPolicy(ctx context.Context, secret *corev1.Secret) DeletionPolicy {
+	value, ok := secret.Annotations[DeletionPolicyAnnotation]
+	if !ok {
+		if r.DeletionPolicy == "" {
+			return DeletionPolicyRetain
+		}
+		return r.DeletionPolicy
+	}
+
+	policy, err := ParseDeletionPolicy(value)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Ignoring deletion policy annotation, retaining resources",
+			"secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+		return DeletionPolicyRetain
+	}
+	return policy
+}
+
+// finalizeSecret removes the backend resources of a deleted secret according to its deletion policy
+func (r *SecretReconciler) finalizeSecret(ctx context.Context, secret *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+	recordEvent := newEventFunc(r.Recorder, secret, actionFinalize)
+
+	policy := r.deletionPolicy(ctx, secret)
+	if policy == DeletionPolicyRetain {
+		logger.Info("Retaining backend resources", "secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+		return nil
+	}
+
+	data, err := decodeSecretData(secret)
+	if err != nil {
+		return err
+	}
+
+	accessKey := getField(data, accessKeyFields...)
+	bucketNames, err := r.provisionedBucketNames(secret, data)
+	if err != nil {
+		logger.Info("Skipping bucket cleanup: " + err.Error())
+	}
+
+	if accessKey == "" {
+		logger.Info("Secret has no access key, nothing to clean up")
+		return nil
+	}
+
+	// Never touch resources on a backend this operator does not manage
+	backend, err := r.backendFor(data)
+	if err != nil {
+		logger.Info("Skipping cleanup: " + err.Error())
+		return nil
+	}
+
+	// Remove the buckets first, while the user still owns them
+	if policy != DeletionPolicyDeleteUser {
+		var errs []error
+		for _, bucketName := range bucketNames {
+			if err := deleteBucket(ctx, backend, recordEvent, bucketName, accessKey, string(secret.UID), policy == DeletionPolicyDeleteAll); err != nil {
+				errs = append(errs, fmt.Errorf("bucket %s: %w", bucketName, err))
+			}
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	return deleteUser(ctx, backend, recordEvent, accessKey)
+}
+
+// provisionedBucketNames returns the bucket names recorded in BucketNameAnnotation when the
+// secret was last reconciled, so buckets are deleted under the names they were created with
+// even if the bucket name template changed since. Secrets without the annotation fall back to
+// resolving their names with the current template.
+func (r *SecretReconciler) provisionedBucketNames(secret *corev1.Secret, data map[string]string) ([]string, error) {
+	if names := splitList(secret.Annotations[BucketNameAnnotation]); len(names) > 0 {
+		return names, nil
+	}
+	return r.bucketNames(secret, data)
+}
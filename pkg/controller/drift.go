@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Drift types reported by the s3_operator_drift_corrections_total metric
+const (
+	DriftUserMissing   = "user_missing"
+	DriftSecretKey     = "secret_key"
+	DriftBucketMissing = "bucket_missing"
+	DriftBucketOwner   = "bucket_owner"
+	DriftBucketPolicy  = "bucket_policy"
+	DriftVersioning    = "bucket_versioning"
+	DriftObjectLock    = "object_lock"
+	DriftLifecycle     = "bucket_lifecycle"
+	DriftQuota         = "bucket_quota"
+	DriftCORS          = "bucket_cors"
+	DriftEncryption    = "bucket_encryption"
+	DriftTags          = "bucket_tags"
+)
+
+// driftTypes maps the events of corrective backend changes to drift types
+var driftTypes = map[string]string{
+	ReasonUserCreated:             DriftUserMissing,
+	ReasonSecretKeyReset:          DriftSecretKey,
+	ReasonBucketCreated:           DriftBucketMissing,
+	ReasonBucketOwnerChanged:      DriftBucketOwner,
+	ReasonBucketPolicyUpdated:     DriftBucketPolicy,
+	ReasonBucketVersioningUpdated: DriftVersioning,
+	ReasonObjectLockUpdated:       DriftObjectLock,
+	ReasonBucketLifecycleUpdated:  DriftLifecycle,
+	ReasonBucketQuotaUpdated:      DriftQuota,
+	ReasonBucketCORSUpdated:       DriftCORS,
+	ReasonBucketEncryptionUpdated: DriftEncryption,
+	ReasonBucketTagsUpdated:       DriftTags,
+}
+
+// countDrift wraps the eventFunc of a resync. Nothing changed on the Kubernetes side since the
+// last successful reconciliation, so every corrective backend change is drift: it is counted
+// and reported as a DriftCorrected warning instead of the regular event.
+func countDrift(ctx context.Context, recordEvent eventFunc) eventFunc {
+	return func(eventType, reason, note string, args ...any) {
+		driftType, ok := driftTypes[reason]
+		if !ok {
+			recordEvent(eventType, reason, note, args...)
+			return
+		}
+
+		metrics.IncrementDriftCorrections(driftType)
+		log.FromContext(ctx).Info("Corrected drift", "type", driftType)
+		recordEvent(corev1.EventTypeWarning, ReasonDriftCorrected, note, args...)
+	}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Field names of the bucket encryption settings
+var (
+	encryptionFields = []string{"encryption", "ENCRYPTION"}
+	kmsKeyIDFields   = []string{"kms-key-id", "KMS_KEY_ID"}
+)
+
+// Encryption modes accepted by ParseEncryption
+const (
+	EncryptionNone   = "none"
+	EncryptionSSES3  = "SSE-S3"
+	EncryptionSSEKMS = "SSE-KMS"
+)
+
+// ParseEncryption parses an encryption mode (SSE-S3, SSE-KMS or none) and KMS key ID. It returns
+// nil for an empty mode or none, which leave the bucket's encryption unmanaged.
+func ParseEncryption(mode, kmsKeyID string) (*backends.BucketEncryption, error) {
+	switch strings.ToUpper(mode) {
+	case "", strings.ToUpper(EncryptionNone):
+		if kmsKeyID != "" {
+			return nil, fmt.Errorf("a KMS key ID requires encryption %s", EncryptionSSEKMS)
+		}
+		return nil, nil
+	case EncryptionSSES3, strings.ToUpper(backends.SSEAlgorithmAES256):
+		if kmsKeyID != "" {
+			return nil, fmt.Errorf("a KMS key ID requires encryption %s, got %s", EncryptionSSEKMS, mode)
+		}
+		return &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmAES256}, nil
+	case EncryptionSSEKMS, strings.ToUpper(backends.SSEAlgorithmKMS):
+		if kmsKeyID == "" {
+			return nil, fmt.Errorf("encryption %s requires a KMS key ID", EncryptionSSEKMS)
+		}
+		return &backends.BucketEncryption{Algorithm: backends.SSEAlgorithmKMS, KMSKeyID: kmsKeyID}, nil
+	default:
+		return nil, fmt.Errorf("encryption must be %s, %s or %s, got %q", EncryptionSSES3, EncryptionSSEKMS, EncryptionNone, mode)
+	}
+}
+
+// bucketEncryption returns the encryption of a secret's bucket. The encryption and kms-key-id
+// fields override the operator's default; a kms-key-id alone selects SSE-KMS.
+func (r *SecretReconciler) bucketEncryption(data map[string]string) (*backends.BucketEncryption, error) {
+	mode := getField(data, encryptionFields...)
+	kmsKeyID := getField(data, kmsKeyIDFields...)
+	if mode == "" && kmsKeyID == "" {
+		return r.Encryption, nil
+	}
+	if mode == "" {
+		mode = EncryptionSSEKMS
+	}
+	return ParseEncryption(mode, kmsKeyID)
+}
+
+// reconcileBucketEncryption makes the default encryption of a bucket match encryption. A nil
+// encryption leaves it unmanaged. Backends that reject the configuration fail with an error
+// wrapping backends.ErrEncryptionRejected.
+func reconcileBucketEncryption(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, encryption *backends.BucketEncryption) error {
+	logger := log.FromContext(ctx)
+
+	if encryption == nil {
+		return nil
+	}
+
+	manager, ok := backend.(backends.EncryptionManager)
+	if !ok {
+		return fmt.Errorf("%w: backend does not support bucket encryption", backends.ErrEncryptionRejected)
+	}
+
+	current, err := manager.GetBucketEncryption(ctx, bucketName)
+	if err != nil {
+		return encryptionFailure("get", bucketName, err)
+	}
+	if current != nil && *current == *encryption {
+		return nil
+	}
+
+	if err := manager.PutBucketEncryption(ctx, bucketName, *encryption); err != nil {
+		return encryptionFailure("set", bucketName, err)
+	}
+
+	logger.Info("Updated bucket encryption", "bucket", bucketName, "algorithm", encryption.Algorithm)
+	recordEvent(corev1.EventTypeNormal, ReasonBucketEncryptionUpdated, "Set encryption of bucket %s to %s", bucketName, encryptionString(*encryption))
+	return nil
+}
+
+// encryptionFailure explains an encryption error, pointing out backends that reject the configuration
+func encryptionFailure(op, bucketName string, err error) error {
+	if errors.Is(err, backends.ErrEncryptionRejected) {
+		return fmt.Errorf("cannot %s encryption of bucket %s, the backend does not support it (set encryption: %s to opt out): %w",
+			op, bucketName, EncryptionNone, err)
+	}
+	return fmt.Errorf("failed to %s bucket encryption: %w", op, err)
+}
+
+// encryptionString describes an encryption for events
+func encryptionString(encryption backends.BucketEncryption) string {
+	if encryption.Algorithm == backends.SSEAlgorithmKMS {
+		return fmt.Sprintf("%s with key %s", EncryptionSSEKMS, encryption.KMSKeyID)
+	}
+	return EncryptionSSES3
+}
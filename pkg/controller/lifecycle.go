@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// lifecycleRulesFields hold the lifecycle rules of a bucket as a YAML or JSON list
+var lifecycleRulesFields = []string{"lifecycle-rules", "LIFECYCLE_RULES"}
+
+// parseLifecycleRules validates the lifecycle-rules field. It returns nil if the field is
+// not set, and an empty, non-nil list if it holds no rules.
+func parseLifecycleRules(value string) ([]backends.LifecycleRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	rules := []backends.LifecycleRule{}
+	if err := yaml.UnmarshalStrict([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("lifecycle-rules is not a valid list of rules: %w", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("lifecycle-rules: every rule needs an id")
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("lifecycle-rules: duplicate rule id %q", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if rule.ExpirationDays < 0 || rule.NoncurrentVersionExpirationDays < 0 || rule.AbortIncompleteMultipartUploadDays < 0 {
+			return nil, fmt.Errorf("lifecycle-rules: rule %q has a negative number of days", rule.ID)
+		}
+		if rule.ExpirationDays == 0 && rule.NoncurrentVersionExpirationDays == 0 && rule.AbortIncompleteMultipartUploadDays == 0 {
+			return nil, fmt.Errorf("lifecycle-rules: rule %q has no action", rule.ID)
+		}
+	}
+	return rules, nil
+}
+
+// reconcileBucketLifecycle makes the lifecycle configuration of a bucket match rules. A nil
+// list leaves the configuration unmanaged, an empty list removes it. Unchanged rules are not
+// rewritten.
+func reconcileBucketLifecycle(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, rules []backends.LifecycleRule) error {
+	logger := log.FromContext(ctx)
+
+	if rules == nil {
+		return nil
+	}
+
+	manager, ok := backend.(backends.LifecycleManager)
+	if !ok {
+		logger.Info("Backend does not support lifecycle rules, skipping", "bucket", bucketName)
+		recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support lifecycle rules, ignoring lifecycle-rules")
+		return nil
+	}
+
+	current, err := manager.GetBucketLifecycle(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	}
+	if lifecycleRulesEqual(current, rules) {
+		return nil
+	}
+
+	if len(rules) == 0 {
+		if err := manager.DeleteBucketLifecycle(ctx, bucketName); err != nil {
+			return fmt.Errorf("failed to delete bucket lifecycle: %w", err)
+		}
+		logger.Info("Removed bucket lifecycle rules", "bucket", bucketName)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketLifecycleUpdated, "Removed lifecycle rules of bucket %s", bucketName)
+		return nil
+	}
+
+	if err := manager.PutBucketLifecycle(ctx, bucketName, rules); err != nil {
+		return fmt.Errorf("failed to put bucket lifecycle: %w", err)
+	}
+	logger.Info("Updated bucket lifecycle rules", "bucket", bucketName, "rules", len(rules))
+	recordEvent(corev1.EventTypeNormal, ReasonBucketLifecycleUpdated, "Updated lifecycle rules of bucket %s", bucketName)
+	return nil
+}
+
+// lifecycleRulesEqual compares two rule lists ignoring their order
+func lifecycleRulesEqual(a, b []backends.LifecycleRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	byID := make(map[string]backends.LifecycleRule, len(a))
+	for _, rule := range a {
+		byID[rule.ID] = rule
+	}
+	for _, rule := range b {
+		other, ok := byID[rule.ID]
+		if !ok || !reflect.DeepEqual(rule, other) {
+			return false
+		}
+	}
+	return true
+}
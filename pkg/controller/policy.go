@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// parseBucketPolicy validates the bucket-policy field. It returns the preset, or an
+// empty preset if the field is empty or holds a raw JSON policy document.
+func parseBucketPolicy(value string) (backends.BucketPolicyPreset, error) {
+	if value == "" {
+		return "", nil
+	}
+	if backends.IsRawBucketPolicy(value) {
+		if !json.Valid([]byte(value)) {
+			return "", fmt.Errorf("bucket-policy is not a valid JSON document")
+		}
+		return "", nil
+	}
+	return backends.ParseBucketPolicyPreset(value)
+}
+
+// grantsSharedAccess reports whether a preset grants a user access to a bucket it does not own
+func grantsSharedAccess(preset backends.BucketPolicyPreset) bool {
+	return preset == backends.BucketPolicyReadOnly || preset == backends.BucketPolicyReadWrite
+}
+
+// reconcileBucketPolicy makes the bucket policy match the secret's bucket-policy field.
+// Presets are merged into the existing policy as a statement for the secret's access key,
+// raw JSON documents replace the whole policy.
+func reconcileBucketPolicy(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName, accessKey, value string, preset backends.BucketPolicyPreset) error {
+	logger := log.FromContext(ctx)
+
+	manager, ok := backend.(backends.BucketPolicyManager)
+	if !ok {
+		if value != "" {
+			logger.Info("Backend does not support bucket policies, skipping", "bucket", bucketName)
+			recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support bucket policies, ignoring bucket-policy")
+		}
+		return nil
+	}
+
+	current, err := manager.GetBucketPolicy(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+
+	var desired string
+	if backends.IsRawBucketPolicy(value) {
+		desired = value
+	} else {
+		// Nothing to remove if we never added a statement
+		if preset == "" && !backends.HasBucketPolicyStatement(current, accessKey) {
+			return nil
+		}
+		desired, err = backends.MergeBucketPolicy(current, bucketName, accessKey, preset)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policiesEqual(current, desired) {
+		return nil
+	}
+
+	if desired == "" {
+		if err := manager.DeleteBucketPolicy(ctx, bucketName); err != nil {
+			return fmt.Errorf("failed to delete bucket policy: %w", err)
+		}
+	} else if err := manager.PutBucketPolicy(ctx, bucketName, desired); err != nil {
+		return fmt.Errorf("failed to put bucket policy: %w", err)
+	}
+
+	metrics.IncrementBucketPoliciesUpdated()
+	logger.Info("Updated bucket policy", "bucket", bucketName, "policy", value)
+	recordEvent(corev1.EventTypeNormal, ReasonBucketPolicyUpdated, "Updated policy of bucket %s", bucketName)
+	return nil
+}
+
+// policiesEqual compares two policy documents ignoring formatting
+func policiesEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if a == "" || b == "" {
+		return false
+	}
+
+	var docA, docB any
+	if json.Unmarshal([]byte(a), &docA) != nil || json.Unmarshal([]byte(b), &docB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(docA, docB)
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Field names of the bucket quota settings
+var (
+	quotaFields        = []string{"quota", "QUOTA"}
+	quotaObjectsFields = []string{"quota-objects", "QUOTA_OBJECTS"}
+)
+
+// parseBucketQuota validates the quota and quota-objects fields. The size accepts plain bytes
+// as well as Kubernetes quantities like 10Gi. It returns nil if neither field is set; a quota of
+// zero removes the bucket's quota.
+func parseBucketQuota(data map[string]string) (*backends.BucketQuota, error) {
+	size := getField(data, quotaFields...)
+	objects := getField(data, quotaObjectsFields...)
+	if size == "" && objects == "" {
+		return nil, nil
+	}
+
+	quota := &backends.BucketQuota{}
+	if size != "" {
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			return nil, fmt.Errorf("quota must be a size in bytes like 1073741824 or 10Gi, got %q", size)
+		}
+		bytes, ok := quantity.AsInt64()
+		if !ok || bytes < 0 {
+			return nil, fmt.Errorf("quota must be a non-negative whole number of bytes, got %q", size)
+		}
+		quota.MaxSize = bytes
+	}
+	if objects != "" {
+		n, err := strconv.ParseInt(objects, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("quota-objects must be a non-negative integer, got %q", objects)
+		}
+		quota.MaxObjects = n
+	}
+	return quota, nil
+}
+
+// reconcileBucketQuota makes the quota of a bucket match quota. A nil quota leaves it unmanaged.
+// Backends without quota support get a warning event instead of failing the reconciliation, as
+// do object count limits on backends that can only cap the size of a bucket.
+func reconcileBucketQuota(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, quota *backends.BucketQuota) error {
+	logger := log.FromContext(ctx)
+
+	if quota == nil {
+		return nil
+	}
+
+	manager, ok := backend.(backends.BucketQuotaManager)
+	if !ok {
+		logger.Info("Backend does not support bucket quotas, skipping", "bucket", bucketName)
+		recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support bucket quotas, ignoring quota")
+		return nil
+	}
+
+	desired := *quota
+	if desired.MaxObjects > 0 && !manager.SupportsObjectQuota() {
+		logger.Info("Backend does not support object count quotas, ignoring quota-objects", "bucket", bucketName)
+		recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support object count quotas, ignoring quota-objects")
+		desired.MaxObjects = 0
+	}
+
+	current, err := manager.GetBucketQuota(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket quota: %w", err)
+	}
+	if current == desired {
+		return nil
+	}
+
+	if err := manager.SetBucketQuota(ctx, bucketName, desired); err != nil {
+		return fmt.Errorf("failed to set bucket quota: %w", err)
+	}
+
+	logger.Info("Updated bucket quota", "bucket", bucketName, "maxSize", desired.MaxSize, "maxObjects", desired.MaxObjects)
+	if desired.IsZero() {
+		recordEvent(corev1.EventTypeNormal, ReasonBucketQuotaUpdated, "Removed quota of bucket %s", bucketName)
+	} else {
+		recordEvent(corev1.EventTypeNormal, ReasonBucketQuotaUpdated, "Set quota of bucket %s to %s", bucketName, quotaString(desired))
+	}
+	return nil
+}
+
+// quotaString describes a quota for events
+func quotaString(quota backends.BucketQuota) string {
+	size := "unlimited size"
+	if quota.MaxSize > 0 {
+		size = resource.NewQuantity(quota.MaxSize, resource.BinarySI).String()
+	}
+	if quota.MaxObjects == 0 {
+		return size
+	}
+	return fmt.Sprintf("%s and %d objects", size, quota.MaxObjects)
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ensureUser creates the user if it does not exist, otherwise updates its IDs and resets
+// its secret key if the backend does not accept the one from the secret
+func ensureUser(ctx context.Context, backend backends.Backend, recordEvent eventFunc, accessKey, secretKey string, role *string, userID, groupID *int) error {
+	userExists, err := backend.UserExists(ctx, accessKey)
+	if err != nil {
+		return fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	if !userExists {
+		if err := backend.CreateUser(ctx, accessKey, secretKey, role, userID, groupID); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		metrics.IncrementUsersCreated()
+		recordEvent(corev1.EventTypeNormal, ReasonUserCreated, "Created user %s", accessKey)
+	} else {
+		// Only reset the secret key if the backend no longer accepts it
+		resetSecretKey := true
+		verifier, canVerify := backend.(backends.CredentialsVerifier)
+		if canVerify {
+			valid, err := verifier.VerifyCredentials(ctx, accessKey, secretKey)
+			if err != nil {
+				return err
+			}
+			resetSecretKey = !valid
+		}
+
+		var newSecretKey *string
+		if resetSecretKey {
+			newSecretKey = &secretKey
+		}
+		if err := backend.UpdateUser(ctx, accessKey, newSecretKey, userID, groupID); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		metrics.IncrementUsersUpdated()
+		if canVerify && resetSecretKey {
+			recordEvent(corev1.EventTypeNormal, ReasonSecretKeyReset, "Reset secret key of user %s", accessKey)
+		}
+	}
+
+	return nil
+}
+
+// ensureBucket creates the bucket owned by owner if it does not exist and reports whether it
+// did. For existing buckets the owner is changed to owner when changeOwner is set. Buckets the
+// backend reports as owned by another account are never created or taken over. New buckets are
+// created with object lock enabled if objectLock is set and the backend supports it.
+func ensureBucket(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName, owner string, changeOwner, objectLock bool) (bool, error) {
+	bucketExists, err := backend.BucketExists(ctx, bucketName)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+
+	if !bucketExists {
+		create := backend.CreateBucket
+		if locker, ok := backend.(backends.ObjectLockManager); ok && objectLock {
+			create = locker.CreateBucketWithObjectLock
+		}
+		if err := create(ctx, bucketName, &owner); err != nil {
+			return false, fmt.Errorf("failed to create bucket: %w", err)
+		}
+		metrics.IncrementBucketsCreated()
+		recordEvent(corev1.EventTypeNormal, ReasonBucketCreated, "Created bucket %s owned by %s", bucketName, owner)
+		return true, nil
+	}
+
+	if changeOwner {
+		// Check if owner needs to be changed
+		currentOwner, err := backend.GetBucketOwner(ctx, bucketName)
+		if err == nil && currentOwner != owner {
+			if err := backend.ChangeBucketOwner(ctx, bucketName, owner); err != nil {
+				return false, fmt.Errorf("failed to change bucket owner: %w", err)
+			}
+			metrics.IncrementBucketOwnersChanged()
+			recordEvent(corev1.EventTypeNormal, ReasonBucketOwnerChanged, "Changed owner of bucket %s from %s to %s", bucketName, currentOwner, owner)
+		}
+	}
+
+	return false, nil
+}
+
+// failureReason returns the event reason for a failed reconciliation
+func failureReason(err error) string {
+	if errors.Is(err, backends.ErrBucketOwnedByOtherAccount) {
+		return ReasonBucketOwnedByOtherAccount
+	}
+	if errors.Is(err, backends.ErrEncryptionRejected) {
+		return ReasonEncryptionRejected
+	}
+	if errors.Is(err, ErrBucketClaimConflict) {
+		return ReasonBucketClaimConflict
+	}
+	if errors.Is(err, backends.ErrSecretKeyChangeUnsupported) {
+		return ReasonUnsupported
+	}
+	return ReasonReconcileFailed
+}
+
+// deleteBucket deletes a bucket owned by accessKey. Non-empty buckets are kept unless purge is set.
+// If claimant is set, buckets claimed by a different secret or S3Bucket UID are kept as well.
+func deleteBucket(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName, accessKey, claimant string, purge bool) error {
+	logger := log.FromContext(ctx)
+
+	exists, err := backend.BucketExists(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to check if bucket exists: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	// Only delete buckets the operator created, never ones it adopted
+	tags, err := bucketTags(ctx, backend, bucketName)
+	if err != nil {
+		return err
+	}
+	if tags[TagOrigin] == BucketOriginAdopted {
+		logger.Info("Retaining adopted bucket", "bucket", bucketName)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketRetained, "Retained bucket %s, it existed before the operator adopted it", bucketName)
+		return nil
+	}
+
+	// Never delete a bucket another secret or S3Bucket took over
+	if claim := tags[TagSecretUID]; claimant != "" && claim != "" && claim != claimant {
+		logger.Info("Retaining bucket claimed by another object", "bucket", bucketName, "claimedBy", claimedBy(tags))
+		recordEvent(corev1.EventTypeNormal, ReasonBucketRetained, "Retained bucket %s claimed by %s", bucketName, claimedBy(tags))
+		return nil
+	}
+
+	// Only delete buckets that belong to this secret's user
+	owner, err := backend.GetBucketOwner(ctx, bucketName)
+	if err == nil && owner != "" && owner != accessKey {
+		logger.Info("Retaining bucket owned by another user", "bucket", bucketName, "owner", owner)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketRetained, "Retained bucket %s owned by %s", bucketName, owner)
+		return nil
+	}
+
+	if purge {
+		if err := backend.EmptyBucket(ctx, bucketName); err != nil {
+			return fmt.Errorf("failed to empty bucket: %w", err)
+		}
+	}
+
+	if err := backend.DeleteBucket(ctx, bucketName); err != nil {
+		// A bucket that is still not empty after purging it is retried rather than retained
+		if errors.Is(err, backends.ErrBucketNotEmpty) && !purge {
+			logger.Info("Retaining non-empty bucket", "bucket", bucketName)
+			recordEvent(corev1.EventTypeNormal, ReasonBucketRetained, "Retained non-empty bucket %s", bucketName)
+			return nil
+		}
+		return fmt.Errorf("failed to delete bucket: %w", err)
+	}
+	metrics.IncrementBucketsDeleted()
+	logger.Info("Deleted bucket", "bucket", bucketName)
+	recordEvent(corev1.EventTypeNormal, ReasonBucketDeleted, "Deleted bucket %s", bucketName)
+
+	return nil
+}
+
+// deleteUser deletes the user if it exists
+func deleteUser(ctx context.Context, backend backends.Backend, recordEvent eventFunc, accessKey string) error {
+	userExists, err := backend.UserExists(ctx, accessKey)
+	if err != nil {
+		return fmt.Errorf("failed to check if user exists: %w", err)
+	}
+	if !userExists {
+		return nil
+	}
+
+	if err := backend.DeleteUser(ctx, accessKey); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	metrics.IncrementUsersDeleted()
+	log.FromContext(ctx).Info("Deleted user", "user", accessKey)
+	recordEvent(corev1.EventTypeNormal, ReasonUserDeleted, "Deleted user %s", accessKey)
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// RotationPeriodAnnotation overrides the operator's default secret key rotation period for a single secret
+	RotationPeriodAnnotation = "s3-resource-operator.io/rotation-period"
+
+	// LastRotatedAnnotation holds the time the secret key was last rotated (RFC 3339)
+	LastRotatedAnnotation = "s3-resource-operator.io/last-rotated"
+)
+
+// ParseRotationPeriod parses a rotation period. Besides Go durations ("2160h") whole days ("90d")
+// are accepted. Zero disables rotation.
+func ParseRotationPeriod(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid rotation period %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	period, err := time.ParseDuration(value)
+	if err != nil || period < 0 {
+		return 0, fmt.Errorf("invalid rotation period %q", value)
+	}
+	return period, nil
+}
+
+// rotationPeriod returns the effective rotation period for a secret.
+// Invalid annotation values disable rotation so that keys are not replaced by accident.
+func (r *SecretReconciler) rotationPeriod(ctx context.Context, secret *corev1.Secret) time.Duration {
+	value, ok := secret.Annotations[RotationPeriodAnnotation]
+	if !ok {
+		return r.RotationPeriod
+	}
+
+	period, err := ParseRotationPeriod(value)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Ignoring rotation period annotation, not rotating",
+			"secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+		return 0
+	}
+	return period
+}
+
+// credentialsIssuedAt returns when the secret key of a secret was last rotated, falling back
+// to the creation time of the secret
+func credentialsIssuedAt(secret *corev1.Secret) time.Time {
+	if value, ok := secret.Annotations[LastRotatedAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return secret.CreationTimestamp.Time
+}
+
+// timeUntilRotation returns the time until the next rotation of a secret's key, or false if
+// rotation is disabled
+func (r *SecretReconciler) timeUntilRotation(ctx context.Context, secret *corev1.Secret) (time.Duration, bool) {
+	period := r.rotationPeriod(ctx, secret)
+	if period <= 0 {
+		return 0, false
+	}
+	return max(time.Until(credentialsIssuedAt(secret).Add(period)), time.Second), true
+}
+
+// rotateSecretKey replaces the secret key of a secret whose rotation period has elapsed. The
+// backend user is updated first, so the secret never holds a key the backend rejected. The secret
+// is then updated with an optimistic lock and together with the rotation timestamp; if that
+// fails, the next reconciliation resets the backend to the key still stored in the secret and
+// rotates again. It returns the secret key to use, which is the current one if no rotation was due.
+func (r *SecretReconciler) rotateSecretKey(ctx context.Context, backend backends.Backend, secret *corev1.Secret, data map[string]string, accessKey, secretKey string, recordEvent eventFunc) (string, error) {
+	period := r.rotationPeriod(ctx, secret)
+	if period <= 0 || time.Since(credentialsIssuedAt(secret)) < period {
+		return secretKey, nil
+	}
+
+	newSecretKey, err := backends.GenerateSecretKey(backend)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret key: %w", err)
+	}
+
+	userExists, err := backend.UserExists(ctx, accessKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if user exists: %w", err)
+	}
+	if userExists {
+		if err := backend.UpdateUser(ctx, accessKey, &newSecretKey, nil, nil); err != nil {
+			return "", fmt.Errorf("failed to rotate secret key: %w", err)
+		}
+	}
+
+	field := secretKeyFields[0]
+	for _, key := range secretKeyFields {
+		if data[key] != "" {
+			field = key
+			break
+		}
+	}
+
+	patch := client.MergeFromWithOptions(secret.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[field] = []byte(newSecretKey)
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[LastRotatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return "", fmt.Errorf("failed to write rotated secret key: %w", err)
+	}
+	data[field] = newSecretKey
+
+	metrics.IncrementSecretKeysRotated()
+	log.FromContext(ctx).Info("Rotated secret key", "user", accessKey)
+	recordEvent(corev1.EventTypeNormal, ReasonSecretKeyRotated, "Rotated secret key of user %s", accessKey)
+	return newSecretKey, nil
+}
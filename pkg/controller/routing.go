@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+)
+
+// backendFields name the backend of the registry a secret belongs to
+var backendFields = []string{"backend", "BACKEND"}
+
+// routingError explains why a secret belongs to none of the operator's backends
+type routingError struct {
+	reason  string
+	message string
+}
+
+func (e *routingError) Error() string {
+	return e.message
+}
+
+// backendFor selects the backend of a secret by its backend field, then by its endpoint URL,
+// falling back to the default backend. Secrets naming an unknown backend, or with an endpoint
+// URL the selected backend does not serve while enforcement is enabled, are not routed.
+func (r *SecretReconciler) backendFor(data map[string]string) (backends.Backend, error) {
+	return routeBackend(r.Backends, r.Backend, r.EnforceEndpoint, data)
+}
+
+// routeBackend selects the backend of a secret from a registry, see backendFor
+func routeBackend(registry *backends.Registry, fallback backends.Backend, enforceEndpoint bool, data map[string]string) (backends.Backend, error) {
+	name := getField(data, backendFields...)
+	endpointURL := getField(data, endpointURLFields...)
+
+	backend := fallback
+	if name != "" {
+		found, ok := registry.Get(name)
+		if !ok {
+			return nil, &routingError{
+				reason:  ReasonUnknownBackend,
+				message: fmt.Sprintf("Backend %s is not configured", name),
+			}
+		}
+		backend = found
+	} else if endpointURL != "" {
+		if found, ok := registry.ForEndpoint(endpointURL); ok {
+			backend = found
+		}
+	}
+
+	if enforceEndpoint && endpointURL != "" && !backends.SameEndpoint(endpointURL, backend.GetEndpointURL()) {
+		return nil, &routingError{
+			reason:  ReasonEndpointMismatch,
+			message: fmt.Sprintf("Endpoint %s does not match operator endpoint %s", endpointURL, backend.GetEndpointURL()),
+		}
+	}
+	return backend, nil
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// S3BucketReconciler reconciles S3Bucket resources with S3 backend
+type S3BucketReconciler struct {
+	client.Client
+	Scheme  *runtime.Scheme
+	Backend backends.Backend
+
+	// Recorder records events on reconciled resources. Events are discarded if nil.
+	Recorder events.EventRecorder
+
+	// ResyncInterval re-verifies the backend resources periodically. Zero disables periodic resyncs.
+	ResyncInterval time.Duration
+
+	// ClusterName, Scope and AnnotationKey must match the SecretReconciler's, so S3Buckets and
+	// secrets respect each other's bucket claims
+	ClusterName   string
+	Scope         WatchScope
+	AnnotationKey string
+
+	// Backends is the operator's backend registry. Referenced secrets selecting a backend of it
+	// other than Backend are rejected.
+	Backends *backends.Registry
+
+	// APIReader reads referenced secrets and the secrets and S3Buckets claiming buckets,
+	// bypassing the cache. Client is used if nil.
+	APIReader client.Reader
+}
+
+// NewS3BucketReconciler creates a new reconciler instance
+func NewS3BucketReconciler(client client.Client, scheme *runtime.Scheme, backend backends.Backend) *S3BucketReconciler {
+	return &S3BucketReconciler{
+		Client:  client,
+		Scheme:  scheme,
+		Backend: backend,
+	}
+}
+
+// Reconcile handles S3Bucket events
+func (r *S3BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var bucket s3v1alpha1.S3Bucket
+	if err := r.Get(ctx, req.NamespacedName, &bucket); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !bucket.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&bucket, CRDFinalizerName) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Info("Finalizing S3Bucket", "namespace", bucket.Namespace, "name", bucket.Name)
+
+		policy := bucket.Spec.DeletionPolicy
+		if policy != "" && policy != s3v1alpha1.BucketDeletionPolicyRetain && bucket.Status.BucketName != "" {
+			purge := policy == s3v1alpha1.BucketDeletionPolicyPurge
+			recordEvent := newEventFunc(r.Recorder, &bucket, actionFinalize)
+			if err := deleteBucket(ctx, r.Backend, recordEvent, bucket.Status.BucketName, bucket.Status.Owner, string(bucket.UID), purge); err != nil {
+				logger.Error(err, "Failed to finalize S3Bucket")
+				metrics.IncrementErrors()
+				return ctrl.Result{}, err
+			}
+		}
+
+		controllerutil.RemoveFinalizer(&bucket, CRDFinalizerName)
+		if err := r.Update(ctx, &bucket); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(&bucket, CRDFinalizerName) {
+		if err := r.Update(ctx, &bucket); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	logger.Info("Reconciling S3Bucket", "namespace", bucket.Namespace, "name", bucket.Name)
+
+	accessKey, _, err := readCredentials(ctx, r.reader(), r.Backends, r.Backend, bucket.Namespace, bucket.Spec.SecretRef)
+	if err != nil {
+		// The secret watch triggers a new reconciliation once the secret is fixed
+		setReadyCondition(&bucket.Status.Conditions, bucket.Generation, metav1.ConditionFalse, credentialsErrorReason(err), err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, &bucket)
+	}
+
+	bucketName := bucket.GetBucketName()
+	if bucket.Status.BucketName != "" && bucket.Status.BucketName != bucketName {
+		setReadyCondition(&bucket.Status.Conditions, bucket.Generation, metav1.ConditionFalse, s3v1alpha1.ReasonInvalidSpec,
+			fmt.Sprintf("bucket name cannot be changed from %q", bucket.Status.BucketName))
+		return ctrl.Result{}, r.updateStatus(ctx, &bucket)
+	}
+
+	preset, err := parseBucketPolicy(bucket.Spec.Policy)
+	if err != nil {
+		setReadyCondition(&bucket.Status.Conditions, bucket.Generation, metav1.ConditionFalse, s3v1alpha1.ReasonInvalidSpec, err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, &bucket)
+	}
+
+	if err := r.handleBucket(ctx, &bucket, bucketName, accessKey, preset); err != nil {
+		logger.Error(err, "Failed to handle S3Bucket")
+		metrics.IncrementErrors()
+		reason := s3v1alpha1.ReasonBackendError
+		switch {
+		case errors.Is(err, backends.ErrBucketOwnedByOtherAccount):
+			reason = s3v1alpha1.ReasonBucketOwnedByOtherAccount
+		case errors.Is(err, ErrBucketClaimConflict):
+			reason = s3v1alpha1.ReasonBucketClaimConflict
+		}
+		setReadyCondition(&bucket.Status.Conditions, bucket.Generation, metav1.ConditionFalse, reason, err.Error())
+		if statusErr := r.updateStatus(ctx, &bucket); statusErr != nil {
+			logger.Error(statusErr, "Failed to update S3Bucket status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	bucket.Status.BucketName = bucketName
+	bucket.Status.Owner = accessKey
+	bucket.Status.ObservedGeneration = bucket.Generation
+	setReadyCondition(&bucket.Status.Conditions, bucket.Generation, metav1.ConditionTrue, s3v1alpha1.ReasonReconciled, "Bucket is provisioned")
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, r.updateStatus(ctx, &bucket)
+}
+
+func (r *S3BucketReconciler) handleBucket(ctx context.Context, bucket *s3v1alpha1.S3Bucket, bucketName, accessKey string, preset backends.BucketPolicyPreset) error {
+	recordEvent := newEventFunc(r.Recorder, bucket, actionReconcile)
+	if isResync(bucket.Status.Conditions, bucket.Generation, bucket.Status.ObservedGeneration) && bucket.Status.Owner == accessKey {
+		recordEvent = countDrift(ctx, recordEvent)
+	}
+
+	userExists, err := r.Backend.UserExists(ctx, accessKey)
+	if err != nil {
+		return fmt.Errorf("failed to check if user exists: %w", err)
+	}
+	if !userExists {
+		return fmt.Errorf("owner %s does not exist on the backend", accessKey)
+	}
+
+	// Buckets claimed by a secret or another S3Bucket are only shared, never taken over
+	claims := r.claims()
+	shared := grantsSharedAccess(preset)
+	claimed, err := claims.claimBucket(ctx, r.Backend, recordEvent, bucket, bucketName, accessKey, shared)
+	if err != nil {
+		recordEvent(corev1.EventTypeWarning, failureReason(err), "%v", err)
+		return err
+	}
+
+	created, err := ensureBucket(ctx, r.Backend, recordEvent, bucketName, accessKey, !shared, false)
+	if err != nil {
+		return err
+	}
+
+	if claimed {
+		if err := reconcileBucketTags(ctx, r.Backend, recordEvent, bucketName, claims.provenanceTags(bucket), nil, created); err != nil {
+			return err
+		}
+	}
+
+	return reconcileBucketPolicy(ctx, r.Backend, recordEvent, bucketName, accessKey, bucket.Spec.Policy, preset)
+}
+
+// reader returns the reader bypassing the cache, see APIReader
+func (r *S3BucketReconciler) reader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// claims returns the bucket claims of the S3Buckets this reconciler manages
+func (r *S3BucketReconciler) claims() bucketClaims {
+	return bucketClaims{reader: r.reader(), clusterName: r.ClusterName, scope: r.Scope, annotationKey: r.AnnotationKey}
+}
+
+func (r *S3BucketReconciler) updateStatus(ctx context.Context, bucket *s3v1alpha1.S3Bucket) error {
+	if err := r.Status().Update(ctx, bucket); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// bucketsForSecret maps a secret to the S3Buckets referencing it
+func (r *S3BucketReconciler) bucketsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var buckets s3v1alpha1.S3BucketList
+	if err := r.List(ctx, &buckets, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list S3Buckets")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, bucket := range buckets.Items {
+		if bucket.Spec.SecretRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bucket.Namespace, Name: bucket.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *S3BucketReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&s3v1alpha1.S3Bucket{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.bucketsForSecret)).
+		Complete(r)
+}
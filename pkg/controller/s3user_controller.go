@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	s3v1alpha1 "github.com/runningman84/s3-resource-operator/api/v1alpha1"
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// S3UserReconciler reconciles S3User resources with S3 backend
+type S3UserReconciler struct {
+	client.Client
+	Scheme  *runtime.Scheme
+	Backend backends.Backend
+
+	// Recorder records events on reconciled resources. Events are discarded if nil.
+	Recorder events.EventRecorder
+
+	// ResyncInterval re-verifies the backend resources periodically. Zero disables periodic resyncs.
+	ResyncInterval time.Duration
+
+	// Backends is the operator's backend registry. Referenced secrets selecting a backend of it
+	// other than Backend are rejected.
+	Backends *backends.Registry
+
+	// APIReader reads referenced secrets, bypassing the cache, which only holds the secrets
+	// matching the operator's label selector. Client is used if nil.
+	APIReader client.Reader
+}
+
+// NewS3UserReconciler creates a new reconciler instance
+func NewS3UserReconciler(client client.Client, scheme *runtime.Scheme, backend backends.Backend) *S3UserReconciler {
+	return &S3UserReconciler{
+		Client:  client,
+		Scheme:  scheme,
+		Backend: backend,
+	}
+}
+
+// reader returns the reader bypassing the cache, see APIReader
+func (r *S3UserReconciler) reader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// Reconcile handles S3User events
+func (r *S3UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var user s3v1alpha1.S3User
+	if err := r.Get(ctx, req.NamespacedName, &user); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !user.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&user, CRDFinalizerName) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Info("Finalizing S3User", "namespace", user.Namespace, "name", user.Name)
+
+		if user.Spec.DeletionPolicy == s3v1alpha1.UserDeletionPolicyDelete && user.Status.AccessKey != "" {
+			recordEvent := newEventFunc(r.Recorder, &user, actionFinalize)
+			if err := deleteUser(ctx, r.Backend, recordEvent, user.Status.AccessKey); err != nil {
+				logger.Error(err, "Failed to finalize S3User")
+				metrics.IncrementErrors()
+				return ctrl.Result{}, err
+			}
+		}
+
+		controllerutil.RemoveFinalizer(&user, CRDFinalizerName)
+		if err := r.Update(ctx, &user); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(&user, CRDFinalizerName) {
+		if err := r.Update(ctx, &user); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	logger.Info("Reconciling S3User", "namespace", user.Namespace, "name", user.Name)
+
+	accessKey, secretKey, err := readCredentials(ctx, r.reader(), r.Backends, r.Backend, user.Namespace, user.Spec.SecretRef)
+	if err != nil {
+		// The secret watch triggers a new reconciliation once the secret is fixed
+		setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionFalse, credentialsErrorReason(err), err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, &user)
+	}
+
+	if err := r.handleUser(ctx, &user, accessKey, secretKey); err != nil {
+		logger.Error(err, "Failed to handle S3User")
+		metrics.IncrementErrors()
+		setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionFalse, s3v1alpha1.ReasonBackendError, err.Error())
+		if statusErr := r.updateStatus(ctx, &user); statusErr != nil {
+			logger.Error(statusErr, "Failed to update S3User status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	user.Status.AccessKey = accessKey
+	user.Status.ObservedGeneration = user.Generation
+	setReadyCondition(&user.Status.Conditions, user.Generation, metav1.ConditionTrue, s3v1alpha1.ReasonReconciled, "User is provisioned")
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, r.updateStatus(ctx, &user)
+}
+
+func (r *S3UserReconciler) handleUser(ctx context.Context, user *s3v1alpha1.S3User, accessKey, secretKey string) error {
+	recordEvent := newEventFunc(r.Recorder, user, actionReconcile)
+	if isResync(user.Status.Conditions, user.Generation, user.Status.ObservedGeneration) && user.Status.AccessKey == accessKey {
+		recordEvent = countDrift(ctx, recordEvent)
+	}
+
+	var role *string
+	if user.Spec.Role != "" {
+		role = &user.Spec.Role
+	}
+
+	if err := ensureUser(ctx, r.Backend, recordEvent, accessKey, secretKey, role, user.Spec.UserID, user.Spec.GroupID); err != nil {
+		return err
+	}
+
+	// The access key in the secret was changed: remove the previous user if it is ours to delete
+	previous := user.Status.AccessKey
+	if previous != "" && previous != accessKey && user.Spec.DeletionPolicy == s3v1alpha1.UserDeletionPolicyDelete {
+		if err := deleteUser(ctx, r.Backend, recordEvent, previous); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *S3UserReconciler) updateStatus(ctx context.Context, user *s3v1alpha1.S3User) error {
+	if err := r.Status().Update(ctx, user); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// usersForSecret maps a secret to the S3Users referencing it
+func (r *S3UserReconciler) usersForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users s3v1alpha1.S3UserList
+	if err := r.List(ctx, &users, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list S3Users")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, user := range users.Items {
+		if user.Spec.SecretRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: user.Namespace, Name: user.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *S3UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&s3v1alpha1.S3User{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.usersForSecret)).
+		Complete(r)
+}
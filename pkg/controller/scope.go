@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WatchScope restricts the secrets the operator watches. The zero value watches every secret
+// in the cluster.
+type WatchScope struct {
+	// Namespaces are the namespaces to watch. All namespaces are watched if empty.
+	Namespaces []string
+	// ExcludeNamespaces are never watched
+	ExcludeNamespaces []string
+	// Selector matches the labels of watched secrets. Every secret matches if nil.
+	Selector labels.Selector
+}
+
+// NewWatchScope builds a WatchScope from comma-separated namespace lists and a label selector
+func NewWatchScope(namespaces, excludeNamespaces, selector string) (WatchScope, error) {
+	scope := WatchScope{
+		Namespaces:        splitList(namespaces),
+		ExcludeNamespaces: splitList(excludeNamespaces),
+	}
+	if selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return WatchScope{}, fmt.Errorf("invalid secret label selector %q: %w", selector, err)
+		}
+		scope.Selector = parsed
+	}
+	scope.Namespaces = slices.DeleteFunc(scope.Namespaces, func(ns string) bool {
+		return slices.Contains(scope.ExcludeNamespaces, ns)
+	})
+	if namespaces != "" && len(scope.Namespaces) == 0 {
+		return WatchScope{}, fmt.Errorf("every watched namespace is excluded")
+	}
+	return scope, nil
+}
+
+// IncludesNamespace reports whether the scope covers a namespace
+func (s WatchScope) IncludesNamespace(namespace string) bool {
+	if slices.Contains(s.ExcludeNamespaces, namespace) {
+		return false
+	}
+	return len(s.Namespaces) == 0 || slices.Contains(s.Namespaces, namespace)
+}
+
+// Includes reports whether the scope covers a secret
+func (s WatchScope) Includes(obj client.Object) bool {
+	if !s.IncludesNamespace(obj.GetNamespace()) {
+		return false
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// CacheOptions restricts the manager's cache to the scope, so the operator only needs read
+// access to secrets in the watched namespaces. The namespaces apply to every cached type, the
+// label selector only to secrets.
+func (s WatchScope) CacheOptions() cache.Options {
+	var opts cache.Options
+
+	switch {
+	case len(s.Namespaces) > 0:
+		opts.DefaultNamespaces = make(map[string]cache.Config, len(s.Namespaces))
+		for _, namespace := range s.Namespaces {
+			opts.DefaultNamespaces[namespace] = cache.Config{}
+		}
+	case len(s.ExcludeNamespaces) > 0:
+		selectors := make([]fields.Selector, 0, len(s.ExcludeNamespaces))
+		for _, namespace := range s.ExcludeNamespaces {
+			selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", namespace))
+		}
+		opts.DefaultNamespaces = map[string]cache.Config{
+			cache.AllNamespaces: {FieldSelector: fields.AndSelectors(selectors...)},
+		}
+	}
+
+	if s.Selector != nil {
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&corev1.Secret{}: {Label: s.Selector},
+		}
+	}
+	return opts
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
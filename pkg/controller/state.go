@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/runningman84/s3-resource-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// secretState is the state the operator annotation of a secret selects
+type secretState string
+
+const (
+	// stateEnabled secrets are reconciled
+	stateEnabled secretState = "enabled"
+	// statePaused secrets keep their backend resources but are neither reconciled nor finalized
+	statePaused secretState = "paused"
+	// stateDisabled secrets are released without touching their backend resources
+	stateDisabled secretState = "disabled"
+)
+
+// annotationState parses the operator annotation of a secret: a true boolean enables the secret,
+// "paused" pauses it, and any other value or a missing annotation disables it
+func (r *SecretReconciler) annotationState(secret *corev1.Secret) secretState {
+	return secretAnnotationState(secret, r.AnnotationKey)
+}
+
+// secretAnnotationState parses the annotation key of a secret, see annotationState
+func secretAnnotationState(secret *corev1.Secret, key string) secretState {
+	value, ok := secret.Annotations[key]
+	if !ok {
+		return stateDisabled
+	}
+	if strings.EqualFold(strings.TrimSpace(value), string(statePaused)) {
+		return statePaused
+	}
+	if enabled, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil && enabled {
+		return stateEnabled
+	}
+	return stateDisabled
+}
+
+// isAnnotated reports whether the operator annotation enables a secret
+func (r *SecretReconciler) isAnnotated(secret *corev1.Secret) bool {
+	return r.annotationState(secret) == stateEnabled
+}
+
+// previousState returns the state the operator last handled a secret in, or "" for secrets it
+// has not handled yet or already released
+func previousState(secret *corev1.Secret) secretState {
+	switch {
+	case secret.Annotations[StatusAnnotation] == string(SecretStatusPaused):
+		return statePaused
+	case controllerutil.ContainsFinalizer(secret, FinalizerName):
+		return stateEnabled
+	}
+	return ""
+}
+
+// releaseSecret removes our finalizer and status from a secret the operator handled before,
+// leaving its backend resources alone
+func (r *SecretReconciler) releaseSecret(ctx context.Context, secret *corev1.Secret, previous secretState) error {
+	r.reconciledVersions.Delete(secret.UID)
+	metrics.DeleteCredentialsIssued(secret.Namespace, secret.Name)
+
+	controllerutil.RemoveFinalizer(secret, FinalizerName)
+	for _, key := range statusAnnotations {
+		delete(secret.Annotations, key)
+	}
+	if err := r.Update(ctx, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	r.recordStateTransition(ctx, secret, previous, stateDisabled)
+	return nil
+}
+
+// recordStateTransition records an event and counts the transition when a secret the operator
+// handled before is enabled, paused or disabled
+func (r *SecretReconciler) recordStateTransition(ctx context.Context, secret *corev1.Secret, from, to secretState) {
+	if from == "" || from == to {
+		return
+	}
+
+	log.FromContext(ctx).Info("Secret state changed", "namespace", secret.Namespace, "name", secret.Name, "from", from, "to", to)
+	metrics.IncrementSecretStateTransitions(string(from), string(to))
+
+	recordEvent := newEventFunc(r.Recorder, secret, actionReconcile)
+	switch to {
+	case stateEnabled:
+		recordEvent(corev1.EventTypeNormal, ReasonResumed, "Reconciliation resumed")
+	case statePaused:
+		recordEvent(corev1.EventTypeNormal, ReasonPaused, "Reconciliation paused, backend resources are left unchanged")
+	case stateDisabled:
+		recordEvent(corev1.EventTypeNormal, ReasonDisabled, "Reconciliation disabled, backend resources are retained")
+	}
+}
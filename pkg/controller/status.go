@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// StatusAnnotation holds the outcome of the last reconciliation of a secret
+	StatusAnnotation = "s3-resource-operator.io/status"
+	// LastReconciledAnnotation holds the time of the last reconciliation of a secret (RFC 3339)
+	LastReconciledAnnotation = "s3-resource-operator.io/last-reconciled"
+	// LastErrorAnnotation holds the error of the last failed reconciliation of a secret
+	LastErrorAnnotation = "s3-resource-operator.io/last-error"
+)
+
+// SecretStatus is the value of the status annotation
+type SecretStatus string
+
+const (
+	// SecretStatusReady means the user and bucket match the secret
+	SecretStatusReady SecretStatus = "Ready"
+	// SecretStatusSkipped means the secret is meant for another backend
+	SecretStatusSkipped SecretStatus = "Skipped"
+	// SecretStatusError means the last reconciliation failed, see the last-error annotation
+	SecretStatusError SecretStatus = "Error"
+	// SecretStatusPaused means the operator annotation pauses the secret's reconciliation
+	SecretStatusPaused SecretStatus = "Paused"
+)
+
+// Event reasons
+const (
+	ReasonUserCreated               = "UserCreated"
+	ReasonUserDeleted               = "UserDeleted"
+	ReasonSecretKeyReset            = "SecretKeyReset"
+	ReasonSecretKeyRotated          = "SecretKeyRotated"
+	ReasonCredentialsGenerated      = "CredentialsGenerated"
+	ReasonBucketCreated             = "BucketCreated"
+	ReasonBucketDeleted             = "BucketDeleted"
+	ReasonBucketRetained            = "BucketRetained"
+	ReasonBucketOwnerChanged        = "BucketOwnerChanged"
+	ReasonBucketPolicyUpdated       = "BucketPolicyUpdated"
+	ReasonBucketVersioningUpdated   = "BucketVersioningUpdated"
+	ReasonObjectLockUpdated         = "ObjectLockUpdated"
+	ReasonBucketLifecycleUpdated    = "BucketLifecycleUpdated"
+	ReasonBucketQuotaUpdated        = "BucketQuotaUpdated"
+	ReasonBucketCORSUpdated         = "BucketCORSUpdated"
+	ReasonBucketEncryptionUpdated   = "BucketEncryptionUpdated"
+	ReasonBucketTagsUpdated         = "BucketTagsUpdated"
+	ReasonBucketAdopted             = "BucketAdopted"
+	ReasonBucketOwnedByOtherAccount = "BucketOwnedByOtherAccount"
+	ReasonEncryptionRejected        = "EncryptionRejected"
+	ReasonBucketClaimConflict       = "BucketClaimConflict"
+	ReasonEndpointMismatch          = "EndpointMismatch"
+	ReasonUnknownBackend            = "UnknownBackend"
+	ReasonMissingFields             = "MissingFields"
+	ReasonInvalidField              = "InvalidField"
+	ReasonUnsupported               = "Unsupported"
+	ReasonReconcileFailed           = "ReconcileFailed"
+	ReasonDriftCorrected            = "DriftCorrected"
+	ReasonPaused                    = "Paused"
+	ReasonResumed                   = "Resumed"
+	ReasonDisabled                  = "Disabled"
+)
+
+// Event actions
+const (
+	actionReconcile = "Reconcile"
+	actionFinalize  = "Finalize"
+)
+
+// eventFunc records an event on the object being reconciled
+type eventFunc func(eventType, reason, note string, args ...any)
+
+// newEventFunc returns an eventFunc recording events on obj. Events are discarded if recorder is nil.
+func newEventFunc(recorder events.EventRecorder, obj runtime.Object, action string) eventFunc {
+	return func(eventType, reason, note string, args ...any) {
+		if recorder != nil {
+			recorder.Eventf(obj, nil, eventType, reason, action, note, args...)
+		}
+	}
+}
+
+// statusAnnotations are written by the reconciler and must not trigger a reconciliation themselves
+var statusAnnotations = []string{StatusAnnotation, LastReconciledAnnotation, LastErrorAnnotation, CORSManagedAnnotation, BucketNameAnnotation}
+
+// updateSecretStatus writes the status annotations for the outcome of a reconciliation
+func (r *SecretReconciler) updateSecretStatus(ctx context.Context, secret *corev1.Secret, status SecretStatus, reconcileErr error) error {
+	patch := client.MergeFrom(secret.DeepCopy())
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[StatusAnnotation] = string(status)
+	secret.Annotations[LastReconciledAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if reconcileErr != nil {
+		secret.Annotations[LastErrorAnnotation] = reconcileErr.Error()
+	} else {
+		delete(secret.Annotations, LastErrorAnnotation)
+	}
+
+	data, _ := decodeSecretData(secret)
+	if bucketNames, err := r.bucketNames(secret, data); err == nil && len(bucketNames) > 0 {
+		secret.Annotations[BucketNameAnnotation] = strings.Join(bucketNames, ",")
+	} else {
+		delete(secret.Annotations, BucketNameAnnotation)
+	}
+
+	return client.IgnoreNotFound(r.Patch(ctx, secret, patch))
+}
+
+// onlyStatusChanged reports whether an update only touched the status annotations
+func onlyStatusChanged(oldObj, newObj client.Object) bool {
+	oldSecret, ok := oldObj.(*corev1.Secret)
+	if !ok {
+		return false
+	}
+	newSecret, ok := newObj.(*corev1.Secret)
+	if !ok {
+		return false
+	}
+
+	return reflect.DeepEqual(oldSecret.Data, newSecret.Data) &&
+		reflect.DeepEqual(oldSecret.StringData, newSecret.StringData) &&
+		reflect.DeepEqual(oldSecret.Labels, newSecret.Labels) &&
+		reflect.DeepEqual(oldSecret.Finalizers, newSecret.Finalizers) &&
+		oldSecret.DeletionTimestamp.Equal(newSecret.DeletionTimestamp) &&
+		maps.Equal(withoutStatusAnnotations(oldSecret.Annotations), withoutStatusAnnotations(newSecret.Annotations))
+}
+
+func withoutStatusAnnotations(annotations map[string]string) map[string]string {
+	result := maps.Clone(annotations)
+	for _, key := range statusAnnotations {
+		delete(result, key)
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// Bucket tags recording which secret a bucket belongs to
+const (
+	// TagPrefix is reserved for the tags written by the operator
+	TagPrefix = "s3-resource-operator.io/"
+
+	TagCluster    = TagPrefix + "cluster"
+	TagNamespace  = TagPrefix + "namespace"
+	TagSecretName = TagPrefix + "secret-name"
+	TagSecretUID  = TagPrefix + "secret-uid"
+	// TagKind records the kind of the object that claimed the bucket if it is not a secret. The
+	// secret-name and secret-uid tags then hold the name and UID of that object.
+	TagKind = TagPrefix + "kind"
+	// TagOrigin records whether the operator created the bucket or adopted an existing one
+	TagOrigin = TagPrefix + "origin"
+)
+
+// Values of the origin tag
+const (
+	BucketOriginCreated = "created"
+	BucketOriginAdopted = "adopted"
+)
+
+// bucketTagsFields hold user-defined bucket tags as a YAML or JSON map
+var bucketTagsFields = []string{"bucket-tags", "BUCKET_TAGS"}
+
+// maxBucketTags is the S3 limit on the number of tags of a bucket
+const maxBucketTags = 50
+
+// parseBucketTags validates the bucket-tags field. It returns nil if the field is not set.
+func parseBucketTags(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	tags := map[string]string{}
+	if err := yaml.UnmarshalStrict([]byte(value), &tags); err != nil {
+		return nil, fmt.Errorf("bucket-tags is not a valid map of tags: %w", err)
+	}
+
+	// Leave room for the tags written by the operator
+	if limit := maxBucketTags - 5; len(tags) > limit {
+		return nil, fmt.Errorf("bucket-tags: at most %d tags are allowed, got %d", limit, len(tags))
+	}
+	for key, value := range tags {
+		switch {
+		case key == "" || len(key) > 128:
+			return nil, fmt.Errorf("bucket-tags: tag keys must be 1 to 128 characters long, got %q", key)
+		case len(value) > 256:
+			return nil, fmt.Errorf("bucket-tags: the value of tag %q is longer than 256 characters", key)
+		case strings.HasPrefix(key, TagPrefix), strings.HasPrefix(strings.ToLower(key), "aws:"):
+			return nil, fmt.Errorf("bucket-tags: tag %q uses a reserved prefix", key)
+		}
+	}
+	return tags, nil
+}
+
+// reconcileBucketTags makes the tags of a bucket match the provenance tags and user-defined
+// tags. The origin tag is set once: created if the operator just created the bucket, adopted
+// otherwise. Backends without tagging only get a warning if the secret defines tags.
+func reconcileBucketTags(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, provenance, userTags map[string]string, created bool) error {
+	logger := log.FromContext(ctx)
+
+	manager, ok := backend.(backends.BucketTaggingManager)
+	if !ok {
+		if userTags != nil {
+			logger.Info("Backend does not support bucket tagging, skipping", "bucket", bucketName)
+			recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support bucket tagging, ignoring bucket-tags")
+		}
+		return nil
+	}
+
+	current, err := manager.GetBucketTagging(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket tags: %w", err)
+	}
+
+	desired := maps.Clone(userTags)
+	if desired == nil {
+		desired = make(map[string]string)
+	}
+	maps.Copy(desired, provenance)
+	desired[TagOrigin] = current[TagOrigin]
+	if desired[TagOrigin] == "" {
+		desired[TagOrigin] = BucketOriginAdopted
+		if created {
+			desired[TagOrigin] = BucketOriginCreated
+		}
+	}
+
+	if maps.Equal(current, desired) {
+		return nil
+	}
+	if err := manager.PutBucketTagging(ctx, bucketName, desired); err != nil {
+		return fmt.Errorf("failed to put bucket tags: %w", err)
+	}
+
+	logger.Info("Updated bucket tags", "bucket", bucketName, "tags", len(desired))
+	// Tagging a new bucket is part of creating it
+	if !created {
+		recordEvent(corev1.EventTypeNormal, ReasonBucketTagsUpdated, "Updated tags of bucket %s", bucketName)
+	}
+	return nil
+}
+
+// bucketTags returns the tags of a bucket, or nil if the backend does not support tagging
+func bucketTags(ctx context.Context, backend backends.Backend, bucketName string) (map[string]string, error) {
+	manager, ok := backend.(backends.BucketTaggingManager)
+	if !ok {
+		return nil, nil
+	}
+	tags, err := manager.GetBucketTagging(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket tags: %w", err)
+	}
+	return tags, nil
+}
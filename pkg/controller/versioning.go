@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/runningman84/s3-resource-operator/pkg/backends"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Field names of the versioning and object lock settings
+var (
+	versioningFields    = []string{"versioning", "VERSIONING"}
+	objectLockFields    = []string{"object-lock", "OBJECT_LOCK"}
+	retentionModeFields = []string{"retention-mode", "RETENTION_MODE"}
+	retentionDaysFields = []string{"retention-days", "RETENTION_DAYS"}
+)
+
+// bucketVersioning holds the versioning and object lock settings of a bucket
+type bucketVersioning struct {
+	// versioning is nil if the secret leaves versioning unmanaged
+	versioning *bool
+	objectLock bool
+	retention  backends.ObjectLockConfiguration
+}
+
+// isSet reports whether any setting is managed
+func (v bucketVersioning) isSet() bool {
+	return v.versioning != nil || v.objectLock
+}
+
+// parseBucketVersioning validates the versioning, object-lock, retention-mode and retention-days fields
+func parseBucketVersioning(data map[string]string) (bucketVersioning, error) {
+	var settings bucketVersioning
+
+	if value := getField(data, versioningFields...); value != "" {
+		enabled, err := parseVersioning(value)
+		if err != nil {
+			return settings, err
+		}
+		settings.versioning = &enabled
+	}
+
+	if value := getField(data, objectLockFields...); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return settings, fmt.Errorf("object-lock must be a boolean, got %q", value)
+		}
+		settings.objectLock = enabled
+	}
+
+	mode := getField(data, retentionModeFields...)
+	days := getField(data, retentionDaysFields...)
+	switch {
+	case mode == "" && days == "":
+		// No default retention
+	case mode == "" || days == "":
+		return settings, fmt.Errorf("retention-mode and retention-days must be set together")
+	default:
+		settings.retention.Mode = strings.ToUpper(mode)
+		if settings.retention.Mode != backends.RetentionModeGovernance && settings.retention.Mode != backends.RetentionModeCompliance {
+			return settings, fmt.Errorf("retention-mode must be %s or %s, got %q", backends.RetentionModeGovernance, backends.RetentionModeCompliance, mode)
+		}
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return settings, fmt.Errorf("retention-days must be a positive integer, got %q", days)
+		}
+		settings.retention.Days = n
+	}
+
+	if settings.retention.Mode != "" && !settings.objectLock {
+		return settings, fmt.Errorf("retention-mode and retention-days require object-lock")
+	}
+	if settings.objectLock && settings.versioning != nil && !*settings.versioning {
+		return settings, fmt.Errorf("object-lock requires versioning")
+	}
+	return settings, nil
+}
+
+// parseVersioning accepts booleans as well as the S3 status names Enabled and Suspended
+func parseVersioning(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "enabled":
+		return true, nil
+	case "suspended":
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("versioning must be a boolean, Enabled or Suspended, got %q", value)
+	}
+	return enabled, nil
+}
+
+// reconcileBucketVersioning makes the versioning and object lock configuration of a bucket match
+// its settings. Object lock is enabled when the bucket is created, see ensureBucket; it cannot be
+// disabled again, so unsetting object-lock leaves the bucket's configuration alone.
+func reconcileBucketVersioning(ctx context.Context, backend backends.Backend, recordEvent eventFunc, bucketName string, settings bucketVersioning) error {
+	logger := log.FromContext(ctx)
+
+	if !settings.isSet() {
+		return nil
+	}
+
+	manager, ok := backend.(backends.BucketVersioningManager)
+	if !ok {
+		logger.Info("Backend does not support bucket versioning, skipping", "bucket", bucketName)
+		recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support bucket versioning, ignoring versioning and object-lock")
+		return nil
+	}
+
+	// Object lock requires versioning
+	desired := settings.objectLock || *settings.versioning
+	current, err := manager.GetBucketVersioning(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket versioning: %w", err)
+	}
+	if current != desired {
+		if err := manager.PutBucketVersioning(ctx, bucketName, desired); err != nil {
+			return fmt.Errorf("failed to put bucket versioning: %w", err)
+		}
+		logger.Info("Updated bucket versioning", "bucket", bucketName, "enabled", desired)
+		recordEvent(corev1.EventTypeNormal, ReasonBucketVersioningUpdated, "Set versioning of bucket %s to %s", bucketName, versioningStatus(desired))
+	}
+
+	if !settings.objectLock {
+		return nil
+	}
+
+	locker, ok := backend.(backends.ObjectLockManager)
+	if !ok {
+		logger.Info("Backend does not support object lock, skipping", "bucket", bucketName)
+		recordEvent(corev1.EventTypeWarning, ReasonUnsupported, "Backend does not support object lock, ignoring object-lock")
+		return nil
+	}
+
+	lock, err := locker.GetObjectLockConfiguration(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+	if lock != nil && *lock == settings.retention {
+		return nil
+	}
+	if err := locker.PutObjectLockConfiguration(ctx, bucketName, settings.retention); err != nil {
+		if lock == nil {
+			return fmt.Errorf("failed to enable object lock on existing bucket %s, most backends only allow it when a bucket is created: %w", bucketName, err)
+		}
+		return fmt.Errorf("failed to put object lock configuration: %w", err)
+	}
+
+	logger.Info("Updated object lock configuration", "bucket", bucketName, "mode", settings.retention.Mode, "days", settings.retention.Days)
+	if settings.retention.Mode == "" {
+		recordEvent(corev1.EventTypeNormal, ReasonObjectLockUpdated, "Removed default retention of bucket %s", bucketName)
+	} else {
+		recordEvent(corev1.EventTypeNormal, ReasonObjectLockUpdated, "Set default retention of bucket %s to %s for %d days",
+			bucketName, settings.retention.Mode, settings.retention.Days)
+	}
+	return nil
+}
+
+func versioningStatus(enabled bool) string {
+	if enabled {
+		return "Enabled"
+	}
+	return "Suspended"
+}
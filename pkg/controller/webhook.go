@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SecretWebhookPath is the path the validating webhook for secrets is served on
+const SecretWebhookPath = "/validate-v1-secret"
+
+// secretValidator rejects annotated secrets the reconciler could not provision, so mistakes
+// surface on kubectl apply instead of as events after the fact
+type secretValidator struct {
+	reconciler *SecretReconciler
+}
+
+// SetupWebhookWithManager registers the validating webhook for secrets at SecretWebhookPath
+func (r *SecretReconciler) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &corev1.Secret{}).
+		WithValidator(&secretValidator{reconciler: r}).
+		WithValidatorCustomPath(SecretWebhookPath).
+		Complete()
+}
+
+// ValidateCreate validates a new secret
+func (v *secretValidator) ValidateCreate(_ context.Context, secret *corev1.Secret) (admission.Warnings, error) {
+	return nil, v.validate(secret)
+}
+
+// ValidateUpdate validates a changed secret. Updates that neither enable the secret nor change
+// its data pass, so the operator can still write the status of secrets created before the
+// webhook was installed and finalize them.
+func (v *secretValidator) ValidateUpdate(_ context.Context, oldSecret, secret *corev1.Secret) (admission.Warnings, error) {
+	if v.reconciler.isAnnotated(oldSecret) && maps.EqualFunc(oldSecret.Data, secret.Data, bytes.Equal) &&
+		maps.Equal(oldSecret.StringData, secret.StringData) {
+		return nil, nil
+	}
+	return nil, v.validate(secret)
+}
+
+// ValidateDelete never rejects a deletion
+func (v *secretValidator) ValidateDelete(context.Context, *corev1.Secret) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks an annotated secret's backend routing and fields the same way the reconciler does
+func (v *secretValidator) validate(secret *corev1.Secret) error {
+	r := v.reconciler
+	if !r.isAnnotated(secret) || !r.Scope.Includes(secret) || !secret.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	data, err := decodeSecretData(secret)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.backendFor(data); err != nil {
+		var routeErr *routingError
+		if errors.As(err, &routeErr) {
+			return errors.New(routeErr.message)
+		}
+		return err
+	}
+
+	// The operator fills in missing credentials of secrets that opted in
+	if generatesCredentials(secret) {
+		for _, fields := range [][]string{accessKeyFields, secretKeyFields} {
+			if getField(data, fields...) == "" {
+				data[fields[0]] = "generated"
+			}
+		}
+	}
+
+	if _, err := r.parseSecret(secret, data); err != nil {
+		return fmt.Errorf("secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -46,12 +47,82 @@ var (
 		Help: "Total number of buckets created",
 	})
 
+	bucketsDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "s3_operator_buckets_deleted_total",
+		Help: "Total number of buckets deleted",
+	})
+
 	bucketOwnersChanged = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "s3_operator_bucket_owners_changed_total",
 		Help: "Total number of bucket owners changed",
 	})
+
+	bucketPoliciesUpdated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "s3_operator_bucket_policies_updated_total",
+		Help: "Total number of bucket policies updated",
+	})
+
+	secretKeysRotated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "s3_operator_secret_keys_rotated_total",
+		Help: "Total number of secret keys rotated",
+	})
+
+	// Drift metrics
+	driftCorrections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "s3_operator_drift_corrections_total",
+		Help: "Total number of backend changes reverted during a resync, by drift type",
+	}, []string{"type"})
+
+	// Secret state metrics
+	secretStateTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "s3_operator_secret_state_transitions_total",
+		Help: "Total number of managed secrets enabled, paused or disabled, by previous and new state",
+	}, []string{"from", "to"})
 )
 
+// credentialsAge reports the age of each managed user's secret key at scrape time
+var credentialsAge = &credentialsAgeCollector{
+	desc: prometheus.NewDesc(
+		"s3_operator_credentials_age_seconds",
+		"Time since the secret key of a managed user was issued or last rotated",
+		[]string{"namespace", "secret", "user"}, nil,
+	),
+}
+
+func init() {
+	prometheus.MustRegister(credentialsAge)
+}
+
+// credentialsAgeKey identifies the secret holding a user's credentials
+type credentialsAgeKey struct {
+	namespace string
+	secret    string
+}
+
+type credentialsAgeEntry struct {
+	user     string
+	issuedAt time.Time
+}
+
+type credentialsAgeCollector struct {
+	desc    *prometheus.Desc
+	entries sync.Map // credentialsAgeKey -> credentialsAgeEntry
+}
+
+func (c *credentialsAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *credentialsAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.entries.Range(func(k, v any) bool {
+		key := k.(credentialsAgeKey)
+		entry := v.(credentialsAgeEntry)
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue,
+			time.Since(entry.issuedAt).Seconds(), key.namespace, key.secret, entry.user)
+		return true
+	})
+}
+
 // Register initializes all metrics (called automatically by promauto)
 func Register() {
 	// Metrics are auto-registered by promauto package
@@ -107,7 +178,42 @@ func IncrementBucketsCreated() {
 	bucketsCreated.Inc()
 }
 
+// IncrementBucketsDeleted increments the buckets deleted counter
+func IncrementBucketsDeleted() {
+	bucketsDeleted.Inc()
+}
+
 // IncrementBucketOwnersChanged increments the bucket owners changed counter
 func IncrementBucketOwnersChanged() {
 	bucketOwnersChanged.Inc()
 }
+
+// IncrementBucketPoliciesUpdated increments the bucket policies updated counter
+func IncrementBucketPoliciesUpdated() {
+	bucketPoliciesUpdated.Inc()
+}
+
+// IncrementDriftCorrections increments the drift corrections counter for a drift type
+func IncrementDriftCorrections(driftType string) {
+	driftCorrections.WithLabelValues(driftType).Inc()
+}
+
+// IncrementSecretStateTransitions increments the state transitions counter of secrets
+func IncrementSecretStateTransitions(from, to string) {
+	secretStateTransitions.WithLabelValues(from, to).Inc()
+}
+
+// IncrementSecretKeysRotated increments the secret keys rotated counter
+func IncrementSecretKeysRotated() {
+	secretKeysRotated.Inc()
+}
+
+// SetCredentialsIssued records when the secret key of the user managed by a secret was issued
+func SetCredentialsIssued(namespace, secret, user string, issuedAt time.Time) {
+	credentialsAge.entries.Store(credentialsAgeKey{namespace, secret}, credentialsAgeEntry{user, issuedAt})
+}
+
+// DeleteCredentialsIssued stops reporting the credentials age of a secret
+func DeleteCredentialsIssued(namespace, secret string) {
+	credentialsAge.entries.Delete(credentialsAgeKey{namespace, secret})
+}
@@ -3,6 +3,9 @@ package metrics
 import (
 	"testing"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestStartTimer(t *testing.T) {
@@ -23,7 +26,44 @@ func TestMetricsIncrement(t *testing.T) {
 	IncrementUsersDeleted()
 	IncrementUsersUpdated()
 	IncrementBucketsCreated()
+	IncrementBucketsDeleted()
 	IncrementBucketOwnersChanged()
+	IncrementBucketPoliciesUpdated()
+	IncrementDriftCorrections("user_missing")
+	IncrementSecretKeysRotated()
+	IncrementSecretStateTransitions("enabled", "paused")
+}
+
+func TestCredentialsAge(t *testing.T) {
+	SetCredentialsIssued("default", "test-secret", "test-user", time.Now().Add(-time.Hour))
+
+	ch := make(chan prometheus.Metric, 10)
+	credentialsAge.Collect(ch)
+	close(ch)
+
+	var found bool
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		if age := metric.GetGauge().GetValue(); age < 3600 || age > 3660 {
+			t.Errorf("expected an age of about one hour, got %v", age)
+		}
+		found = true
+	}
+	if !found {
+		t.Fatal("expected credentials age to be reported")
+	}
+
+	DeleteCredentialsIssued("default", "test-secret")
+
+	ch = make(chan prometheus.Metric, 10)
+	credentialsAge.Collect(ch)
+	close(ch)
+	if len(ch) != 0 {
+		t.Error("expected credentials age to be removed")
+	}
 }
 
 func TestMetricsDuration(t *testing.T) {